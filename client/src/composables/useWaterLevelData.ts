@@ -1,4 +1,5 @@
 import { computed, ref } from 'vue'
+import type { ConditionReadingDto } from '@/dto/condition-reading.dto'
 
 export function useWaterLevelData() {
   const showWaterLevelAlert = ref(false)
@@ -89,11 +90,11 @@ export function useWaterLevelData() {
       const res = await fetch(`${import.meta.env.VITE_BACKEND_API_URL}/conditions/water`)
       if (!res.ok) throw new Error('Backend error')
 
-      const data = await res.json()
+      const data: { water_level: ConditionReadingDto<number>; water_flow: ConditionReadingDto<number> } = await res.json()
 
-      requestDate.value = new Date(data.request_date).toLocaleTimeString()
-      currentWaterLevel.value = data.water_level
-      currentWaterFlow.value = data.water_flow
+      requestDate.value = new Date(data.water_level.observed_at).toLocaleTimeString()
+      currentWaterLevel.value = data.water_level.value
+      currentWaterFlow.value = data.water_flow.value
 
       showWaterLevelAlert.value = currentWaterLevel.value !== null && currentWaterLevel.value <= 140
 
@@ -121,20 +122,15 @@ export function useWaterLevelData() {
       const res = await fetch(`${import.meta.env.VITE_BACKEND_API_URL}/conditions/water/history`)
       if (!res.ok) throw new Error('Backend error')
   
-      const data = await res.json()
+      const data: ConditionReadingDto<{ timestamp: string; value: number }[]> = await res.json()
   
       historyLabels.value = []
       historyValues.value = []
       historyTimestamps.value = []
   
-      const parsedData = data
-        .map((entry: any) => {
-          const [datePart, timePart] = entry.DateTime.split(' ')
-          const [day, month, year] = datePart.split('.')
-  
-          const isoString = `${year}-${month}-${day}T${timePart}:00`
-  
-          const parsedDate = new Date(isoString)
+      const parsedData = data.value
+        .map((entry) => {
+          const parsedDate = new Date(entry.timestamp)
           if (isNaN(parsedDate.getTime())) return null
   
           return {
@@ -145,7 +141,7 @@ export function useWaterLevelData() {
               minute: '2-digit',
             }),
             timestamp: parsedDate.toISOString(),
-            value: entry.Value,
+            value: entry.value,
           }
         })
         .filter(Boolean)
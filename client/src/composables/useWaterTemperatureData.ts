@@ -31,11 +31,11 @@ export function useTemperature() {
     try {
       const res = await axios.get(`${API_BASE_URL}/conditions/water/temperature`)
       const data: WaterTemperatureDto = res.data
-      waterTemperature.value = data.water_temperature
+      waterTemperature.value = data.value
       cacheTimestamp.value = Date.now()
   
       localStorage.setItem(STORAGE_KEY, JSON.stringify({
-        temperature: data.water_temperature,
+        temperature: data.value,
         timestamp: cacheTimestamp.value,
       }))
     } catch (err) {
@@ -0,0 +1,9 @@
+export interface ConditionReadingDto<T> {
+    value: T
+    unit: string
+    observed_at: string // when the upstream measured the value
+    fetched_at: string // when the server fetched it
+    source: string
+    station_id?: string
+    stale: boolean
+  }
@@ -1,9 +1,22 @@
+// Mirrors PredictionResponse in the server's OpenAPI spec (/api/v1/openapi.json)
+export interface CalendarDayDto {
+    date: string // YYYY-MM-DD
+    weekday: string
+    is_weekend: boolean
+    public_holiday?: string
+    school_holiday?: string
+}
+
 export interface PredictionResponseDto {
     hour: number
     water_temperature: number
     water_level: number
     air_temperature: number
-    weather_condition: string
+    weather_condition: number // WMO weather code, -1 if unknown
+    is_daylight: boolean
     prediction: number
-    explanation: Record<string, number>  
-}  
\ No newline at end of file
+    source: 'ml' | 'rule_based'
+    model_version: string
+    explanation: Record<string, number> | null // per-feature contributions; is_weekend, is_public_holiday and is_school_holiday for rule-based predictions, null if the model gives none
+    calendar: CalendarDayDto
+}
@@ -1,6 +1,10 @@
+// Mirrors SurferEntry in the server's OpenAPI spec (/api/v1/openapi.json)
 export interface SurferEntryDto {
     timestamp: string
     count: number
     water_temperature: number // being passed to the server, cause takes longer than the other values to fetch; if null, it is being fetched again from the server 
+    air_temperature: number
+    weather_condition: number // WMO weather code, -1 if unknown
+    water_level: number
+    water_flow: number
   }
-  
\ No newline at end of file
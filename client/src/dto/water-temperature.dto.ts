@@ -1,4 +1,3 @@
-export interface WaterTemperatureDto {
-    water_temperature: number
-  }
-  
\ No newline at end of file
+import type { ConditionReadingDto } from './condition-reading.dto'
+
+export type WaterTemperatureDto = ConditionReadingDto<number>
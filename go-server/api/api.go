@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix is the namespace of the current API version. The same routes under /api
+// are deprecated aliases that keep the old response format for existing clients.
+const Prefix = "/api/v1"
+
+// DeprecatedSince is when the unversioned routes were deprecated, sent in the Deprecation header
+var DeprecatedSince = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// Envelope wraps every successful JSON response of the versioned API. It holds nothing
+// request specific (the request ID is in the X-Request-ID header), so equal data gives equal
+// bodies and responses can be cached and compared by ETag.
+type Envelope struct {
+	Data any `json:"data"`
+}
+
+// IsLegacy tells whether r is for one of the deprecated unversioned routes
+func IsLegacy(r *http.Request) bool {
+	path := r.URL.Path
+	return strings.HasPrefix(path, "/api/") && path != Prefix && !strings.HasPrefix(path, Prefix+"/")
+}
+
+// Successor is the versioned path of a deprecated unversioned one
+func Successor(path string) string {
+	return Prefix + strings.TrimPrefix(path, "/api")
+}
+
+// JSON writes v with status, in an Envelope unless r is for a deprecated route
+func JSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	if !IsLegacy(r) {
+		v = Envelope{Data: v}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Deprecated marks the responses of an unversioned alias with the Deprecation header
+// and links the versioned route that replaces it
+func Deprecated(handler http.HandlerFunc) http.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(DeprecatedSince.Unix(), 10)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Add("Link", "<"+Successor(r.URL.Path)+`>; rel="successor-version"`)
+		handler(w, r)
+	}
+}
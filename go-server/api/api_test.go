@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/logging"
+)
+
+func TestJSONWrapsVersionedResponsesOnly(t *testing.T) {
+	payload := map[string]int{"prediction": 12}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/surfers/predict", nil)
+	req = req.WithContext(logging.WithRequestID(req.Context(), "req-1"))
+	rec := httptest.NewRecorder()
+	JSON(rec, req, http.StatusOK, payload)
+	t.Logf("v1: %s", strings.TrimSpace(rec.Body.String()))
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"data":{"prediction":12}}` {
+		t.Errorf("unexpected versioned body %s", got)
+	}
+
+	rec = httptest.NewRecorder()
+	JSON(rec, httptest.NewRequest(http.MethodGet, "/api/surfers/predict", nil), http.StatusOK, payload)
+	t.Logf("legacy: %s", strings.TrimSpace(rec.Body.String()))
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"prediction":12}` {
+		t.Errorf("unexpected legacy body %s", got)
+	}
+}
+
+func TestErrorWritesProblemDetails(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/conditions/daylight?date=tomorrow", nil)
+	rec := httptest.NewRecorder()
+	Error(rec, req, http.StatusBadRequest, CodeInvalidParameter, "Invalid date, expected YYYY-MM-DD")
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("expected problem+json, got %q", ct)
+	}
+	var problem Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	t.Logf("problem: %+v", problem)
+	if problem.Status != http.StatusBadRequest || problem.Code != CodeInvalidParameter ||
+		problem.Type != ProblemTypePrefix+"invalid_parameter" || problem.Instance != "/api/v1/conditions/daylight" {
+		t.Errorf("unexpected problem %+v", problem)
+	}
+
+	rec = httptest.NewRecorder()
+	Error(rec, httptest.NewRequest(http.MethodGet, "/api/conditions/daylight", nil), http.StatusBadRequest, CodeInvalidParameter, "Invalid date, expected YYYY-MM-DD")
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected plain text for a deprecated route, got %q", ct)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != "Invalid date, expected YYYY-MM-DD" {
+		t.Errorf("unexpected legacy error %q", got)
+	}
+}
+
+func TestDeprecatedLinksSuccessor(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Deprecated(func(w http.ResponseWriter, r *http.Request) {})(rec, httptest.NewRequest(http.MethodPost, "/api/admin/models/v3/promote", nil))
+
+	if got := rec.Header().Get("Deprecation"); got != "@1792368000" {
+		t.Errorf("unexpected Deprecation header %q", got)
+	}
+	if got := rec.Header().Get("Link"); got != `</api/v1/admin/models/v3/promote>; rel="successor-version"` {
+		t.Errorf("unexpected Link header %q", got)
+	}
+}
+
+func TestIsLegacy(t *testing.T) {
+	for path, legacy := range map[string]bool{
+		"/api/surfers":      true,
+		"/api/openapi.json": true,
+		"/api/v1/surfers":   false,
+		"/api/v1":           false,
+		"/api/v10/surfers":  true,
+		"/healthz":          false,
+		"/metrics":          false,
+	} {
+		if got := IsLegacy(httptest.NewRequest(http.MethodGet, path, nil)); got != legacy {
+			t.Errorf("IsLegacy(%q) = %v, expected %v", path, got, legacy)
+		}
+	}
+}
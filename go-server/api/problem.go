@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/logging"
+)
+
+// Code identifies the kind of an error. Codes are stable; clients may switch on them.
+type Code string
+
+const (
+	CodeInvalidParameter    Code = "invalid_parameter"
+	CodeInvalidBody         Code = "invalid_body"
+	CodeBodyTooLarge        Code = "body_too_large"
+	CodeNotFound            Code = "not_found"
+	CodeMethodNotAllowed    Code = "method_not_allowed"
+	CodeUnauthorized        Code = "unauthorized"
+	CodeAdminDisabled       Code = "admin_disabled"
+	CodeConflict            Code = "conflict"
+	CodeDuplicateSubmission Code = "duplicate_submission"
+	CodeRateLimited         Code = "rate_limited"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeInternal            Code = "internal_error"
+)
+
+// ProblemTypePrefix + code is the type URI of a problem
+const ProblemTypePrefix = "urn:eisbachtracker:problem:"
+
+// Problem is an RFC 7807 problem details object, extended with the error code and request ID
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      Code   `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Error writes an application/problem+json response. Deprecated routes get the old
+// plain text body (detail) instead. detail is shown to clients: never put internal errors in it.
+func Error(w http.ResponseWriter, r *http.Request, status int, code Code, detail string) {
+	if IsLegacy(r) {
+		if detail == "" {
+			detail = http.StatusText(status)
+		}
+		http.Error(w, detail, status)
+		return
+	}
+
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:      ProblemTypePrefix + string(code),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: logging.RequestID(r.Context()),
+	})
+}
+
+// MethodNotAllowed answers with 405 and the methods the route supports
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	Error(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+}
+
+// NotFound answers requests for unknown versioned routes
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Error(w, r, http.StatusNotFound, CodeNotFound, "No such route")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/db"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
+)
+
+// runBackfillConditions replaces placeholder conditions of stored entries with measured
+// values from GKD, HND and the Open-Meteo archive; the report is written to stdout:
+//
+//	go run . backfill-conditions -dry-run -from 2025-05-01
+func runBackfillConditions(args []string) error {
+	flags := flag.NewFlagSet("backfill-conditions", flag.ContinueOnError)
+	from := flags.String("from", "", "first day (YYYY-MM-DD) or time (RFC 3339) of entries to backfill")
+	to := flags.String("to", "", "last day (YYYY-MM-DD, inclusive) or time (RFC 3339, exclusive) of entries to backfill")
+	limit := flags.Int("limit", 0, "backfill at most this many entries, oldest first (0 for all)")
+	dryRun := flags.Bool("dry-run", false, "look up the values and report without storing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := surferdata.BackfillOptions{Limit: *limit, DryRun: *dryRun}
+	var err error
+	if opts.From, err = surferdata.ParseTimeBound(*from, false); err != nil {
+		return err
+	}
+	if opts.To, err = surferdata.ParseTimeBound(*to, true); err != nil {
+		return err
+	}
+
+	if err := db.Init(); err != nil {
+		return err
+	}
+	defer db.Conn.Close()
+
+	history := conditions.NewHistoryService(conditions.NewOpenMeteoArchiveClient(
+		config.Weather.OpenMeteoArchiveURL, config.Spot.Latitude, config.Spot.Longitude))
+	report, err := surferdata.NewService(db.Conn, nil, nil).BackfillConditions(context.Background(), history, opts)
+	if err != nil {
+		return fmt.Errorf("failed to backfill conditions: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	slog.Info("backfilled conditions", "dry_run", *dryRun, "entries", report.Entries, "updated", report.Updated)
+	return nil
+}
@@ -0,0 +1,102 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+)
+
+// Day holds the calendar features of a local date that influence crowds
+type Day struct {
+	Date          string       `json:"date"` // YYYY-MM-DD
+	Weekday       time.Weekday `json:"-"`
+	WeekdayName   string       `json:"weekday"`
+	IsWeekend     bool         `json:"is_weekend"`
+	PublicHoliday string       `json:"public_holiday,omitempty"`
+	SchoolHoliday string       `json:"school_holiday,omitempty"`
+}
+
+// For returns the calendar features of t's local date (in t's location)
+func For(t time.Time) Day {
+	date := t.Format("2006-01-02")
+	weekday := t.Weekday()
+
+	day := Day{
+		Date:        date,
+		Weekday:     weekday,
+		WeekdayName: weekday.String(),
+		IsWeekend:   weekday == time.Saturday || weekday == time.Sunday,
+	}
+
+	if name, ok := BavarianHolidays(t.Year())[date]; ok {
+		day.PublicHoliday = name
+	}
+	for _, h := range config.Calendar.SchoolHolidays {
+		// Dates are YYYY-MM-DD, so string order is date order
+		if date >= h.Start && date <= h.End {
+			day.SchoolHoliday = h.Name
+			break
+		}
+	}
+
+	return day
+}
+
+func (d Day) IsPublicHoliday() bool { return d.PublicHoliday != "" }
+func (d Day) IsSchoolHoliday() bool { return d.SchoolHoliday != "" }
+
+// IsDayOff reports whether most people don't work: weekends and public holidays
+func (d Day) IsDayOff() bool { return d.IsWeekend || d.IsPublicHoliday() }
+
+// MondayBasedWeekday returns 0 for Monday through 6 for Sunday
+func (d Day) MondayBasedWeekday() int {
+	return (int(d.Weekday) + 6) % 7
+}
+
+// BavarianHolidays returns the public holidays in Munich for a year, keyed by YYYY-MM-DD.
+// Mariä Himmelfahrt is included since Munich is a predominantly Catholic municipality.
+func BavarianHolidays(year int) map[string]string {
+	easter := Easter(year)
+	fixed := func(month time.Month, day int) string {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+	}
+	fromEaster := func(days int) string {
+		return easter.AddDate(0, 0, days).Format("2006-01-02")
+	}
+
+	return map[string]string{
+		fixed(time.January, 1):   "Neujahr",
+		fixed(time.January, 6):   "Heilige Drei Könige",
+		fromEaster(-2):           "Karfreitag",
+		fromEaster(1):            "Ostermontag",
+		fixed(time.May, 1):       "Tag der Arbeit",
+		fromEaster(39):           "Christi Himmelfahrt",
+		fromEaster(50):           "Pfingstmontag",
+		fromEaster(60):           "Fronleichnam",
+		fixed(time.August, 15):   "Mariä Himmelfahrt",
+		fixed(time.October, 3):   "Tag der Deutschen Einheit",
+		fixed(time.November, 1):  "Allerheiligen",
+		fixed(time.December, 25): "1. Weihnachtstag",
+		fixed(time.December, 26): "2. Weihnachtstag",
+	}
+}
+
+// Easter returns Easter Sunday of the Gregorian calendar (anonymous Gregorian algorithm)
+func Easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,61 @@
+package calendar
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+)
+
+func TestEaster(t *testing.T) {
+	want := map[int]string{
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2026: "2026-04-05",
+		2038: "2038-04-25",
+	}
+	for year, date := range want {
+		if got := Easter(year).Format("2006-01-02"); got != date {
+			t.Errorf("Easter(%d) = %s, want %s", year, got, date)
+		}
+	}
+}
+
+func TestBavarianHolidaysIncludeEasterBasedDays(t *testing.T) {
+	holidays := BavarianHolidays(2025)
+
+	for date, name := range map[string]string{
+		"2025-04-18": "Karfreitag",
+		"2025-05-29": "Christi Himmelfahrt",
+		"2025-06-09": "Pfingstmontag",
+		"2025-06-19": "Fronleichnam",
+		"2025-08-15": "Mariä Himmelfahrt",
+	} {
+		if holidays[date] != name {
+			t.Errorf("expected %s on %s, got %q", name, date, holidays[date])
+		}
+	}
+	if len(holidays) != 13 {
+		t.Errorf("expected 13 public holidays, got %d", len(holidays))
+	}
+}
+
+func TestForCombinesWeekendHolidaysAndSchoolHolidays(t *testing.T) {
+	os.Setenv("CALENDAR_CONFIG", "../config/calendar.toml")
+	if err := config.LoadCalendarConfig(); err != nil {
+		t.Fatalf("Failed to load calendar config: %v", err)
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	corpusChristi := For(time.Date(2025, 6, 19, 14, 0, 0, 0, berlin))
+	if !corpusChristi.IsDayOff() || corpusChristi.IsWeekend || corpusChristi.SchoolHoliday != "Pfingstferien" {
+		t.Errorf("unexpected features for Fronleichnam 2025: %+v", corpusChristi)
+	}
+
+	tuesday := For(time.Date(2025, 10, 14, 14, 0, 0, 0, berlin))
+	if tuesday.IsDayOff() || tuesday.IsSchoolHoliday() || tuesday.MondayBasedWeekday() != 1 {
+		t.Errorf("expected a plain working Tuesday: %+v", tuesday)
+	}
+}
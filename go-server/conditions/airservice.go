@@ -1,42 +1,31 @@
 package conditions
 
 import (
-	"encoding/json"
-	"net/http"
+	"context"
 )
 
-type AirService struct{}
+type AirService struct {
+	openMeteo *OpenMeteoClient
+}
 
-func NewAirService() *AirService {
-	return &AirService{}
+func NewAirService(openMeteo *OpenMeteoClient) *AirService {
+	return &AirService{openMeteo: openMeteo}
 }
 
 type AirDataProvider interface {
-	GetCurrentWeather() (*WeatherData, error)
+	GetCurrentWeather(ctx context.Context) (*WeatherData, error)
+	GetForecast(ctx context.Context) (*WeatherForecast, error)
 }
 
-func (ws *AirService) GetCurrentWeather() (*WeatherData, error) {
-	url := "https://api.open-meteo.com/v1/forecast?latitude=48.137154&longitude=11.576124&current_weather=true"
-
-	resp, err := http.Get(url)
+func (ws *AirService) GetCurrentWeather(ctx context.Context) (*WeatherData, error) {
+	forecast, err := ws.GetForecast(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var apiResp struct {
-		CurrentWeather struct {
-			Temp        float64 `json:"temperature"`
-			WeatherCode int     `json:"weathercode"`
-		} `json:"current_weather"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, err
-	}
+	return forecast.CurrentWeather(), nil
+}
 
-	return &WeatherData{
-		Temp:      apiResp.CurrentWeather.Temp,
-		Condition: apiResp.CurrentWeather.WeatherCode, // Use numeric WeatherCode directly
-	}, nil
+// GetForecast returns current conditions plus hourly and daily forecasts in metric units
+func (ws *AirService) GetForecast(ctx context.Context) (*WeatherForecast, error) {
+	return ws.openMeteo.Forecast(ctx)
 }
@@ -0,0 +1,224 @@
+package conditions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/health"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrCircuitOpen is returned when an upstream has failed repeatedly and there is no usable cached value
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CachePolicy controls how a Cached source is refreshed and protected
+type CachePolicy struct {
+	TTL              time.Duration // values younger than this are served without refetching
+	MaxStale         time.Duration // older values are still served (and revalidated in the background) up to this age
+	FetchTimeout     time.Duration // bounds a single upstream fetch, independent of the caller
+	FailureThreshold int           // consecutive failures that open the breaker
+	OpenDuration     time.Duration // how long the breaker stays open before a half-open trial fetch
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Cached wraps a fetch function with a TTL cache, stale-while-revalidate,
+// single-flight deduplication and a circuit breaker.
+type Cached[T any] struct {
+	name   string
+	policy CachePolicy
+	fetch  func(ctx context.Context) (T, error)
+	now    func() time.Time
+
+	mu        sync.Mutex
+	value     T
+	hasValue  bool
+	fetchedAt time.Time
+	inflight  *fetchCall[T]
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+}
+
+type fetchCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func NewCached[T any](name string, policy CachePolicy, fetch func(ctx context.Context) (T, error)) *Cached[T] {
+	return &Cached[T]{
+		name:   name,
+		policy: policy,
+		fetch:  fetch,
+		now:    time.Now,
+	}
+}
+
+// Get returns a fresh value from the cache, a stale one (kicking off a background
+// revalidation), or waits for a fetch as long as ctx allows.
+func (c *Cached[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+
+	c.mu.Lock()
+	age := c.now().Sub(c.fetchedAt)
+
+	if c.hasValue && age < c.policy.TTL {
+		value := c.value
+		c.mu.Unlock()
+		c.record(ctx, CacheHit, age)
+		return value, nil
+	}
+
+	if c.hasValue && age < c.policy.MaxStale {
+		c.startFetchLocked(ctx)
+		value := c.value
+		c.mu.Unlock()
+		c.record(ctx, CacheStale, age)
+		return value, nil
+	}
+
+	call := c.startFetchLocked(ctx)
+	c.mu.Unlock()
+
+	if call == nil {
+		metrics.CacheRequests.WithLabelValues(c.name, cacheError).Inc()
+		return zero, fmt.Errorf("%s: %w", c.name, ErrCircuitOpen)
+	}
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		metrics.CacheRequests.WithLabelValues(c.name, cacheError).Inc()
+		return zero, ctx.Err()
+	}
+
+	if call.err != nil {
+		metrics.CacheRequests.WithLabelValues(c.name, cacheError).Inc()
+		return zero, call.err
+	}
+	c.record(ctx, CacheMiss, 0)
+	return call.value, nil
+}
+
+// cacheError is the metrics result of lookups that returned no value
+const cacheError = "error"
+
+func (c *Cached[T]) record(ctx context.Context, status CacheStatus, age time.Duration) {
+	metrics.CacheRequests.WithLabelValues(c.name, string(status)).Inc()
+	trace.SpanFromContext(ctx).AddEvent("cache "+string(status),
+		trace.WithAttributes(attribute.String("upstream.provider", c.name), attribute.Float64("cache.age_seconds", age.Seconds())))
+	recordCacheEvent(ctx, c.name, status, age, c.policy.TTL)
+}
+
+// Refresh forces a fetch (joining one already in flight) and waits for it
+func (c *Cached[T]) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	call := c.startFetchLocked(ctx)
+	c.mu.Unlock()
+
+	if call == nil {
+		return fmt.Errorf("%s: %w", c.name, ErrCircuitOpen)
+	}
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startFetchLocked returns the in-flight fetch, starts a new one, or returns nil
+// if the breaker doesn't allow a fetch right now. c.mu must be held.
+func (c *Cached[T]) startFetchLocked(ctx context.Context) *fetchCall[T] {
+	if c.inflight != nil {
+		return c.inflight
+	}
+
+	switch c.state {
+	case breakerOpen:
+		if c.now().Sub(c.openedAt) < c.policy.OpenDuration {
+			return nil
+		}
+		c.state = breakerHalfOpen
+	case breakerHalfOpen:
+		return nil
+	}
+
+	call := &fetchCall[T]{done: make(chan struct{})}
+	c.inflight = call
+
+	// Detached from the caller: one request giving up must not cancel a fetch others are waiting on
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.policy.FetchTimeout)
+	go func() {
+		defer cancel()
+		spanCtx, span := tracing.Start(fetchCtx, "fetch "+c.name, attribute.String("upstream.provider", c.name))
+		started := time.Now()
+		value, err := c.fetch(spanCtx)
+		metrics.ObserveUpstream(c.name, time.Since(started), err)
+		health.Upstreams.Observe(c.name, err)
+		tracing.End(span, err)
+		c.finish(fetchCtx, call, value, err)
+	}()
+
+	return call
+}
+
+func (c *Cached[T]) finish(ctx context.Context, call *fetchCall[T], value T, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inflight = nil
+	call.value, call.err = value, err
+
+	if err == nil {
+		c.value = value
+		c.hasValue = true
+		c.fetchedAt = c.now()
+		c.failures = 0
+		c.state = breakerClosed
+	} else {
+		c.failures++
+		if c.state == breakerHalfOpen || c.failures >= c.policy.FailureThreshold {
+			c.state = breakerOpen
+			c.openedAt = c.now()
+		}
+		slog.WarnContext(ctx, "upstream fetch failed",
+			"source", c.name, "err", err, "failures", c.failures, "breaker", c.state.String())
+	}
+
+	metrics.CircuitOpen.WithLabelValues(c.name).Set(boolToFloat(c.state == breakerOpen))
+	close(call.done)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
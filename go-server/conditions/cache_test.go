@@ -0,0 +1,218 @@
+package conditions
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+)
+
+var testPolicy = CachePolicy{
+	TTL:              time.Minute,
+	MaxStale:         time.Hour,
+	FetchTimeout:     time.Second,
+	FailureThreshold: 2,
+	OpenDuration:     10 * time.Minute,
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestCached(fetch func(ctx context.Context) (int, error)) (*Cached[int], *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	c := NewCached("test", testPolicy, fetch)
+	c.now = clock.Now
+	return c, clock
+}
+
+func TestCachedServesFreshValueWithoutRefetching(t *testing.T) {
+	var calls atomic.Int32
+	c, clock := newTestCached(func(ctx context.Context) (int, error) {
+		return int(calls.Add(1)), nil
+	})
+
+	first, err := c.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	clock.Advance(30 * time.Second)
+	second, _ := c.Get(context.Background())
+
+	if first != 1 || second != 1 || calls.Load() != 1 {
+		t.Errorf("expected one fetch serving both calls, got %d/%d after %d fetches", first, second, calls.Load())
+	}
+}
+
+func TestCacheTraceFreshForIsRemainingTTLOfOldestValue(t *testing.T) {
+	c, clock := newTestCached(func(ctx context.Context) (int, error) { return 1, nil })
+	other, _ := newTestCached(func(ctx context.Context) (int, error) { return 2, nil })
+	other.name = "other"
+	other.now = clock.Now
+
+	c.Get(context.Background())
+	clock.Advance(40 * time.Second)
+
+	ctx, trace := WithCacheTrace(context.Background())
+	c.Get(ctx)     // hit, 20s of its TTL left
+	other.Get(ctx) // miss, the full minute left
+	fresh, ok := trace.FreshFor()
+	t.Logf("fresh for %s", fresh)
+	if !ok || fresh != 20*time.Second {
+		t.Errorf("expected 20s, got %s (ok=%v)", fresh, ok)
+	}
+
+	clock.Advance(time.Minute)
+	ctx, trace = WithCacheTrace(context.Background())
+	c.Get(ctx) // stale
+	if fresh, _ := trace.FreshFor(); fresh != 0 {
+		t.Errorf("expected a stale value to be fresh for 0, got %s", fresh)
+	}
+	if _, ok := (&CacheTrace{}).FreshFor(); ok {
+		t.Error("expected ok=false without cached sources")
+	}
+}
+
+func TestCachedDeduplicatesConcurrentFetches(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	c, _ := newTestCached(func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.Get(context.Background()); err != nil || v != 42 {
+				t.Errorf("Get = %d, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected a single upstream fetch, got %d", calls.Load())
+	}
+}
+
+func TestCachedServesStaleOnErrorWithinMaxStale(t *testing.T) {
+	fail := atomic.Bool{}
+	c, clock := newTestCached(func(ctx context.Context) (int, error) {
+		if fail.Load() {
+			return 0, errors.New("upstream down")
+		}
+		return 7, nil
+	})
+
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatalf("initial Get failed: %v", err)
+	}
+
+	fail.Store(true)
+	clock.Advance(30 * time.Minute)
+
+	ctx, trace := WithCacheTrace(context.Background())
+	v, err := c.Get(ctx)
+	if err != nil || v != 7 {
+		t.Fatalf("expected stale value 7, got %d, %v", v, err)
+	}
+	if event := trace.Events()["test"]; event.Status != CacheStale || event.Age != 30*time.Minute {
+		t.Errorf("unexpected cache event: %+v", event)
+	}
+
+	clock.Advance(2 * time.Hour)
+	if _, err := c.Get(context.Background()); err == nil {
+		t.Error("expected an error once the value is older than MaxStale")
+	}
+}
+
+func TestCachedBreakerOpensAndHalfOpens(t *testing.T) {
+	var calls atomic.Int32
+	fail := atomic.Bool{}
+	fail.Store(true)
+	c, clock := newTestCached(func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		if fail.Load() {
+			return 0, errors.New("upstream down")
+		}
+		return 1, nil
+	})
+
+	for i := 0; i < testPolicy.FailureThreshold; i++ {
+		c.Get(context.Background())
+	}
+
+	if _, err := c.Get(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != int32(testPolicy.FailureThreshold) {
+		t.Errorf("open breaker should not call upstream, got %d calls", calls.Load())
+	}
+
+	// Half-open trial fails → open again
+	clock.Advance(testPolicy.OpenDuration)
+	if _, err := c.Get(context.Background()); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the half-open trial to reach upstream, got %v", err)
+	}
+	if _, err := c.Get(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected breaker to re-open after failed trial, got %v", err)
+	}
+
+	// Half-open trial succeeds → closed
+	fail.Store(false)
+	clock.Advance(testPolicy.OpenDuration)
+	if v, err := c.Get(context.Background()); err != nil || v != 1 {
+		t.Fatalf("expected recovery, got %d, %v", v, err)
+	}
+}
+
+func TestCachedRecordsMetrics(t *testing.T) {
+	fail := false
+	c := NewCached("metrics-test", testPolicy, func(ctx context.Context) (int, error) {
+		if fail {
+			return 0, errors.New("upstream down")
+		}
+		return 1, nil
+	})
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	c.now = clock.Now
+
+	c.Get(context.Background()) // miss
+	c.Get(context.Background()) // hit
+	clock.Advance(2 * time.Hour)
+	fail = true
+	c.Get(context.Background()) // too old to serve stale, fetch fails
+
+	for result, want := range map[string]float64{"miss": 1, "hit": 1, "error": 1} {
+		if got := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("metrics-test", result)); got != want {
+			t.Errorf("cache_requests_total{result=%q} = %v, want %v", result, got, want)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.UpstreamRequests.WithLabelValues("metrics-test", metrics.OutcomeError)); got != 1 {
+		t.Errorf("expected one failed upstream fetch, got %v", got)
+	}
+}
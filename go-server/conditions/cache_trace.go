@@ -0,0 +1,94 @@
+package conditions
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type CacheStatus string
+
+const (
+	CacheHit   CacheStatus = "hit"
+	CacheStale CacheStatus = "stale"
+	CacheMiss  CacheStatus = "miss"
+)
+
+// CacheEvent describes how a cached source answered during a request
+type CacheEvent struct {
+	Status CacheStatus
+	Age    time.Duration
+	TTL    time.Duration // how long the source's values count as fresh
+}
+
+// CacheTrace collects the cache events of every cached source used while
+// handling a request, so the response can be annotated with the data age.
+type CacheTrace struct {
+	mu     sync.Mutex
+	events map[string]CacheEvent
+}
+
+type cacheTraceKey struct{}
+
+// WithCacheTrace returns a context that records cache events into the returned trace
+func WithCacheTrace(ctx context.Context) (context.Context, *CacheTrace) {
+	trace := &CacheTrace{events: map[string]CacheEvent{}}
+	return context.WithValue(ctx, cacheTraceKey{}, trace), trace
+}
+
+func recordCacheEvent(ctx context.Context, source string, status CacheStatus, age, ttl time.Duration) {
+	trace, ok := ctx.Value(cacheTraceKey{}).(*CacheTrace)
+	if !ok {
+		return
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.events[source] = CacheEvent{Status: status, Age: age, TTL: ttl}
+}
+
+// Events returns a copy of the recorded events keyed by source name
+func (t *CacheTrace) Events() map[string]CacheEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := make(map[string]CacheEvent, len(t.events))
+	for source, event := range t.events {
+		events[source] = event
+	}
+	return events
+}
+
+// Summary returns the age of the oldest value served and the "worst" status
+// (stale > hit > miss). ok is false if no cached source was used.
+func (t *CacheTrace) Summary() (age time.Duration, status CacheStatus, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rank := map[CacheStatus]int{CacheMiss: 0, CacheHit: 1, CacheStale: 2}
+	for _, event := range t.events {
+		if !ok || rank[event.Status] > rank[status] {
+			status = event.Status
+		}
+		if event.Age > age {
+			age = event.Age
+		}
+		ok = true
+	}
+	return age, status, ok
+}
+
+// FreshFor returns how long until the first of the values served leaves its TTL,
+// zero if one is stale already. ok is false if no cached source was used.
+func (t *CacheTrace) FreshFor() (fresh time.Duration, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, event := range t.events {
+		remaining := max(event.TTL-event.Age, 0)
+		if !ok || remaining < fresh {
+			fresh = remaining
+		}
+		ok = true
+	}
+	return fresh, ok
+}
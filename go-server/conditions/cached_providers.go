@@ -0,0 +1,116 @@
+package conditions
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Cache policies per upstream, based on how often each source actually updates
+var (
+	// Open-Meteo current weather updates every 15 minutes
+	WeatherCachePolicy = CachePolicy{
+		TTL:              10 * time.Minute,
+		MaxStale:         3 * time.Hour,
+		FetchTimeout:     upstreamTimeout,
+		FailureThreshold: 3,
+		OpenDuration:     time.Minute,
+	}
+
+	// GKD only publishes a daily mean water temperature
+	WaterTemperatureCachePolicy = CachePolicy{
+		TTL:              60 * time.Minute,
+		MaxStale:         48 * time.Hour,
+		FetchTimeout:     gkdTimeout,
+		FailureThreshold: 2,
+		OpenDuration:     10 * time.Minute,
+	}
+
+	// PegelAlarm level and flow update every 15 minutes
+	WaterLevelCachePolicy = CachePolicy{
+		TTL:              5 * time.Minute,
+		MaxStale:         2 * time.Hour,
+		FetchTimeout:     upstreamTimeout,
+		FailureThreshold: 3,
+		OpenDuration:     time.Minute,
+	}
+
+	// HND history table covers several days; a new row every 15 minutes
+	WaterHistoryCachePolicy = CachePolicy{
+		TTL:              30 * time.Minute,
+		MaxStale:         12 * time.Hour,
+		FetchTimeout:     upstreamTimeout,
+		FailureThreshold: 3,
+		OpenDuration:     5 * time.Minute,
+	}
+)
+
+// CachedAirService decorates an AirDataProvider with caching and a circuit breaker.
+// Current weather is taken from the cached forecast, so both share one upstream call.
+type CachedAirService struct {
+	forecast *Cached[*WeatherForecast]
+}
+
+func NewCachedAirService(inner AirDataProvider) *CachedAirService {
+	return &CachedAirService{
+		forecast: NewCached(SourceOpenMeteo, WeatherCachePolicy, inner.GetForecast),
+	}
+}
+
+func (c *CachedAirService) GetCurrentWeather(ctx context.Context) (*WeatherData, error) {
+	forecast, err := c.forecast.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return forecast.CurrentWeather(), nil
+}
+
+func (c *CachedAirService) GetForecast(ctx context.Context) (*WeatherForecast, error) {
+	return c.forecast.Get(ctx)
+}
+
+// CachedWaterService decorates a WaterDataProvider with caching and a circuit breaker per source
+type CachedWaterService struct {
+	temperature  *Cached[*WaterTemperature]
+	levelAndFlow *Cached[*WaterLevelAndFlow]
+	history      *Cached[*WaterLevelHistory]
+}
+
+func NewCachedWaterService(inner WaterDataProvider) *CachedWaterService {
+	return &CachedWaterService{
+		temperature:  NewCached(SourceGKD, WaterTemperatureCachePolicy, inner.GetLatestWaterTemperature),
+		levelAndFlow: NewCached(SourcePegelAlarm, WaterLevelCachePolicy, inner.GetLatestWaterLevelAndFlow),
+		history:      NewCached(SourceHND, WaterHistoryCachePolicy, inner.GetHistoricalWaterLevels),
+	}
+}
+
+func (c *CachedWaterService) GetLatestWaterTemperature(ctx context.Context) (*WaterTemperature, error) {
+	return c.temperature.Get(ctx)
+}
+
+func (c *CachedWaterService) GetLatestWaterLevelAndFlow(ctx context.Context) (*WaterLevelAndFlow, error) {
+	return c.levelAndFlow.Get(ctx)
+}
+
+func (c *CachedWaterService) GetHistoricalWaterLevels(ctx context.Context) (*WaterLevelHistory, error) {
+	return c.history.Get(ctx)
+}
+
+// PollWaterTemperature keeps the water temperature cache warm so requests
+// don't have to wait for the GKD download. Runs until ctx is cancelled.
+func (c *CachedWaterService) PollWaterTemperature(ctx context.Context) {
+	ticker := time.NewTicker(WaterTemperatureCachePolicy.TTL)
+	defer ticker.Stop()
+
+	for {
+		if err := c.temperature.Refresh(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("background water temperature refresh failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package conditions
+
+import (
+	"math"
+	"time"
+)
+
+// Sun altitudes (degrees) that define the events below. -0.833° accounts for
+// atmospheric refraction and the sun's radius.
+const (
+	sunriseAltitude  = -0.833
+	civilAltitude    = -6.0
+	julianUnixEpoch  = 2440587.5
+	julianJ2000      = 2451545.0
+	earthObliquity   = 23.4397
+	secondsPerDayJul = 86400.0
+)
+
+// Daylight holds the sun events of one local day at one location.
+// Times are zero when the event doesn't happen (polar day or night).
+type Daylight struct {
+	Date      string        `json:"date"` // local date, YYYY-MM-DD
+	Latitude  float64       `json:"latitude"`
+	Longitude float64       `json:"longitude"`
+	CivilDawn time.Time     `json:"civil_dawn"`
+	Sunrise   time.Time     `json:"sunrise"`
+	SolarNoon time.Time     `json:"solar_noon"`
+	Sunset    time.Time     `json:"sunset"`
+	CivilDusk time.Time     `json:"civil_dusk"`
+	DayLength time.Duration `json:"-"`
+	DayHours  float64       `json:"day_length_hours"`
+	// SurfableWindow is nil when it doesn't get light at all
+	SurfableWindow *SurfableWindow `json:"surfable_window,omitempty"`
+}
+
+// SurfableWindow is the part of a day with enough light to surf, civil dawn to civil dusk
+type SurfableWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Hours float64   `json:"hours"`
+}
+
+// SunTimes computes sunrise, sunset and civil twilight for the local day of date
+// (in date's location) using the NOAA sunrise equation. Accurate to about a minute.
+func SunTimes(date time.Time, latitude, longitude float64) Daylight {
+	loc := date.Location()
+	y, m, d := date.Date()
+
+	// Julian day number of the calendar date at noon UTC
+	noonUTC := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	jd := float64(noonUTC.Unix())/secondsPerDayJul + julianUnixEpoch
+
+	n := math.Round(jd - julianJ2000 + 0.0008)
+	meanSolarNoon := n - longitude/360
+
+	meanAnomaly := math.Mod(357.5291+0.98560028*meanSolarNoon, 360)
+	mRad := rad(meanAnomaly)
+	center := 1.9148*math.Sin(mRad) + 0.0200*math.Sin(2*mRad) + 0.0003*math.Sin(3*mRad)
+	eclipticLongitude := math.Mod(meanAnomaly+center+180+102.9372, 360)
+	lRad := rad(eclipticLongitude)
+
+	transit := julianJ2000 + meanSolarNoon + 0.0053*math.Sin(mRad) - 0.0069*math.Sin(2*lRad)
+	declination := math.Asin(math.Sin(lRad) * math.Sin(rad(earthObliquity)))
+
+	event := func(altitude float64, rising bool) time.Time {
+		cosHourAngle := (math.Sin(rad(altitude)) - math.Sin(rad(latitude))*math.Sin(declination)) /
+			(math.Cos(rad(latitude)) * math.Cos(declination))
+		if cosHourAngle < -1 || cosHourAngle > 1 {
+			return time.Time{}
+		}
+		offset := deg(math.Acos(cosHourAngle)) / 360
+		if rising {
+			offset = -offset
+		}
+		return fromJulian(transit + offset).In(loc)
+	}
+
+	daylight := Daylight{
+		Date:      date.Format("2006-01-02"),
+		Latitude:  latitude,
+		Longitude: longitude,
+		CivilDawn: event(civilAltitude, true),
+		Sunrise:   event(sunriseAltitude, true),
+		SolarNoon: fromJulian(transit).In(loc),
+		Sunset:    event(sunriseAltitude, false),
+		CivilDusk: event(civilAltitude, false),
+	}
+	if !daylight.Sunrise.IsZero() {
+		daylight.DayLength = daylight.Sunset.Sub(daylight.Sunrise)
+	} else if declination*latitude > 0 {
+		daylight.DayLength = 24 * time.Hour // polar day
+	}
+	daylight.DayHours = math.Round(daylight.DayLength.Hours()*100) / 100
+
+	switch {
+	case !daylight.CivilDawn.IsZero() && !daylight.CivilDusk.IsZero():
+		daylight.SurfableWindow = newSurfableWindow(daylight.CivilDawn, daylight.CivilDusk)
+	case daylight.IsLight(daylight.SolarNoon): // the sun stays above civil twilight all day
+		midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		daylight.SurfableWindow = newSurfableWindow(midnight, midnight.AddDate(0, 0, 1))
+	}
+
+	return daylight
+}
+
+func newSurfableWindow(start, end time.Time) *SurfableWindow {
+	return &SurfableWindow{Start: start, End: end, Hours: math.Round(end.Sub(start).Hours()*100) / 100}
+}
+
+// IsLight reports whether t falls between civil dawn and civil dusk
+func (d Daylight) IsLight(t time.Time) bool {
+	if d.CivilDawn.IsZero() {
+		return d.DayLength > 0
+	}
+	return !t.Before(d.CivilDawn) && t.Before(d.CivilDusk)
+}
+
+// IsSunUp reports whether t falls between sunrise and sunset
+func (d Daylight) IsSunUp(t time.Time) bool {
+	if d.Sunrise.IsZero() {
+		return d.DayLength > 0
+	}
+	return !t.Before(d.Sunrise) && t.Before(d.Sunset)
+}
+
+func rad(deg float64) float64 { return deg * math.Pi / 180 }
+func deg(rad float64) float64 { return rad * 180 / math.Pi }
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(int64(math.Round((j-julianUnixEpoch)*secondsPerDayJul)), 0)
+}
+
+// spotLocation buckets entries into local hours and days; set from config.Spot.Location
+var spotLocation = munich
+
+// AtLocalHour returns hour:00 on t's day in the spot's local time. On the day the clocks
+// go forward the skipped hour is normalized like time.Date does (02:00 becomes 03:00).
+func AtLocalHour(t time.Time, hour int) time.Time {
+	local := t.In(spotLocation)
+	return time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, spotLocation)
+}
+
+// SpotLocation is the time zone of the spot
+func SpotLocation() *time.Location { return spotLocation }
+
+// SetSpotLocation changes the spot's time zone; call it before creating any clients
+func SetSpotLocation(loc *time.Location) {
+	if loc != nil {
+		spotLocation = loc
+	}
+}
@@ -0,0 +1,107 @@
+package conditions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSunTimesMunich(t *testing.T) {
+	cases := []struct {
+		date             time.Time
+		sunrise, sunset  string
+		minHours, maxHrs float64
+	}{
+		{time.Date(2025, 6, 21, 0, 0, 0, 0, munich), "05:12", "21:16", 16, 16.5},
+		{time.Date(2025, 12, 21, 0, 0, 0, 0, munich), "08:01", "16:22", 8.2, 8.5},
+	}
+
+	for _, c := range cases {
+		d := SunTimes(c.date, 48.137154, 11.576124)
+		t.Logf("%s: dawn %s, sunrise %s, sunset %s, dusk %s", d.Date,
+			d.CivilDawn.Format("15:04"), d.Sunrise.Format("15:04"), d.Sunset.Format("15:04"), d.CivilDusk.Format("15:04"))
+
+		if !withinMinutes(d.Sunrise, c.sunrise, 2) || !withinMinutes(d.Sunset, c.sunset, 2) {
+			t.Errorf("%s: expected sunrise %s / sunset %s, got %s / %s", d.Date, c.sunrise, c.sunset,
+				d.Sunrise.Format("15:04"), d.Sunset.Format("15:04"))
+		}
+		if d.DayHours < c.minHours || d.DayHours > c.maxHrs {
+			t.Errorf("%s: unexpected day length %.2fh", d.Date, d.DayHours)
+		}
+		if !d.CivilDawn.Before(d.Sunrise) || !d.CivilDusk.After(d.Sunset) {
+			t.Errorf("%s: civil twilight must surround sunrise and sunset", d.Date)
+		}
+	}
+}
+
+func TestSurfableWindow(t *testing.T) {
+	d := SunTimes(time.Date(2025, 6, 21, 0, 0, 0, 0, munich), 48.137154, 11.576124)
+	t.Logf("%+v", d.SurfableWindow)
+	if d.SurfableWindow == nil || !d.SurfableWindow.Start.Equal(d.CivilDawn) || !d.SurfableWindow.End.Equal(d.CivilDusk) {
+		t.Fatalf("expected civil dawn to civil dusk, got %+v", d.SurfableWindow)
+	}
+	if d.SurfableWindow.Hours <= d.DayHours || d.SurfableWindow.Hours > 18 {
+		t.Errorf("expected the window to include twilight, got %.2fh for a %.2fh day", d.SurfableWindow.Hours, d.DayHours)
+	}
+
+	// White night in Tromsø: the sun never sets, light all day
+	polarDay := SunTimes(time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC), 69.65, 18.96)
+	if w := polarDay.SurfableWindow; w == nil || w.Hours != 24 {
+		t.Errorf("expected a 24h window on a polar day, got %+v", w)
+	}
+	// Longyearbyen in December: not even civil twilight
+	if w := SunTimes(time.Date(2025, 12, 21, 0, 0, 0, 0, time.UTC), 78.22, 15.65).SurfableWindow; w != nil {
+		t.Errorf("expected no window in polar night, got %+v", w)
+	}
+}
+
+func TestSunTimesPolarNight(t *testing.T) {
+	d := SunTimes(time.Date(2025, 12, 21, 0, 0, 0, 0, time.UTC), 78.22, 15.65) // Longyearbyen
+	if !d.Sunrise.IsZero() || d.DayLength != 0 || d.IsSunUp(time.Date(2025, 12, 21, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected polar night, got %+v", d)
+	}
+}
+
+func TestAtLocalHourAcrossDST(t *testing.T) {
+	cases := []struct {
+		now  time.Time
+		hour int
+		want time.Time
+	}{
+		{time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC), 12, time.Date(2025, 3, 30, 10, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC), 2, time.Date(2025, 3, 30, 1, 0, 0, 0, time.UTC)}, // no 2am
+		{time.Date(2025, 10, 26, 9, 0, 0, 0, time.UTC), 12, time.Date(2025, 10, 26, 11, 0, 0, 0, time.UTC)},
+		// Still Saturday in UTC, already Sunday in Munich
+		{time.Date(2025, 10, 25, 22, 30, 0, 0, time.UTC), 12, time.Date(2025, 10, 26, 11, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if got := AtLocalHour(c.now, c.hour); !got.Equal(c.want) {
+			t.Errorf("AtLocalHour(%s, %d) = %s, want %s", c.now, c.hour, got, c.want.In(munich))
+		}
+	}
+}
+
+func TestSetSpotLocation(t *testing.T) {
+	t.Cleanup(func() { SetSpotLocation(munich) })
+
+	lisbon := mustLoadLocation("Europe/Lisbon")
+	SetSpotLocation(lisbon)
+	SetSpotLocation(nil) // ignored
+	if SpotLocation() != lisbon {
+		t.Fatalf("expected Europe/Lisbon, got %s", SpotLocation())
+	}
+	got := AtLocalHour(time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC), 12)
+	if want := time.Date(2025, 7, 1, 11, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected noon in Lisbon at %s, got %s", want, got)
+	}
+}
+
+func withinMinutes(got time.Time, want string, tolerance float64) bool {
+	w, err := time.ParseInLocation("15:04", want, got.Location())
+	if err != nil {
+		return false
+	}
+	gotMinutes := float64(got.Hour()*60 + got.Minute())
+	wantMinutes := float64(w.Hour()*60 + w.Minute())
+	diff := gotMinutes - wantMinutes
+	return diff <= tolerance && diff >= -tolerance
+}
@@ -0,0 +1,83 @@
+package conditions
+
+import "fmt"
+
+// ForecastUnits uses the Open-Meteo unit names
+type ForecastUnits struct {
+	Temperature   string `json:"temperature"`   // celsius | fahrenheit
+	WindSpeed     string `json:"wind_speed"`    // kmh | ms | mph | kn
+	Precipitation string `json:"precipitation"` // mm | inch
+}
+
+var MetricUnits = ForecastUnits{Temperature: "celsius", WindSpeed: "kmh", Precipitation: "mm"}
+
+var (
+	temperatureFromCelsius = map[string]func(float64) float64{
+		"celsius":    func(c float64) float64 { return c },
+		"fahrenheit": func(c float64) float64 { return c*9/5 + 32 },
+	}
+	windSpeedFromKmh = map[string]func(float64) float64{
+		"kmh": func(v float64) float64 { return v },
+		"ms":  func(v float64) float64 { return v / 3.6 },
+		"mph": func(v float64) float64 { return v / 1.609344 },
+		"kn":  func(v float64) float64 { return v / 1.852 },
+	}
+	precipitationFromMm = map[string]func(float64) float64{
+		"mm":   func(v float64) float64 { return v },
+		"inch": func(v float64) float64 { return v / 25.4 },
+	}
+)
+
+func (u ForecastUnits) Validate() error {
+	if _, ok := temperatureFromCelsius[u.Temperature]; !ok {
+		return fmt.Errorf("unsupported temperature unit %q", u.Temperature)
+	}
+	if _, ok := windSpeedFromKmh[u.WindSpeed]; !ok {
+		return fmt.Errorf("unsupported wind speed unit %q", u.WindSpeed)
+	}
+	if _, ok := precipitationFromMm[u.Precipitation]; !ok {
+		return fmt.Errorf("unsupported precipitation unit %q", u.Precipitation)
+	}
+	return nil
+}
+
+// InUnits returns a copy of a metric forecast converted to the given units
+func (f *WeatherForecast) InUnits(u ForecastUnits) (*WeatherForecast, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	temp := temperatureFromCelsius[u.Temperature]
+	wind := windSpeedFromKmh[u.WindSpeed]
+	precip := precipitationFromMm[u.Precipitation]
+
+	out := *f
+	out.Units = u
+
+	out.Current.Temperature = temp(f.Current.Temperature)
+	out.Current.ApparentTemperature = temp(f.Current.ApparentTemperature)
+	out.Current.Precipitation = precip(f.Current.Precipitation)
+	out.Current.WindSpeed = wind(f.Current.WindSpeed)
+	out.Current.WindGusts = wind(f.Current.WindGusts)
+
+	out.Hourly = make([]HourlyForecast, len(f.Hourly))
+	for i, h := range f.Hourly {
+		h.Temperature = temp(h.Temperature)
+		h.ApparentTemperature = temp(h.ApparentTemperature)
+		h.Precipitation = precip(h.Precipitation)
+		h.WindSpeed = wind(h.WindSpeed)
+		h.WindGusts = wind(h.WindGusts)
+		out.Hourly[i] = h
+	}
+
+	out.Daily = make([]DailyForecast, len(f.Daily))
+	for i, d := range f.Daily {
+		d.TemperatureMax = temp(d.TemperatureMax)
+		d.TemperatureMin = temp(d.TemperatureMin)
+		d.PrecipitationSum = precip(d.PrecipitationSum)
+		d.WindSpeedMax = wind(d.WindSpeedMax)
+		d.WindGustsMax = wind(d.WindGustsMax)
+		out.Daily[i] = d
+	}
+
+	return &out, nil
+}
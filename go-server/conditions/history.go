@@ -0,0 +1,152 @@
+package conditions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryField is a condition that can be looked up for past times, named like its surfer_entries column
+type HistoryField string
+
+const (
+	HistoryWaterTemperature HistoryField = "water_temperature"
+	HistoryWaterLevel       HistoryField = "water_level"
+	HistoryWaterFlow        HistoryField = "water_flow"
+	HistoryAirTemperature   HistoryField = "air_temperature"
+	HistoryWeatherCondition HistoryField = "weather_condition"
+)
+
+// Measurement is one value of a historical series
+type Measurement struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Series is a historical time series of one field. Each value stands for the Interval
+// starting at its time; daily means (Interval 24h) stand for their local calendar day.
+// ObservedAt of the provenance is left zero, each measurement has its own time.
+type Series struct {
+	Values   []Measurement
+	Interval time.Duration
+	Provenance
+}
+
+// At returns the value covering t
+func (s *Series) At(t time.Time) (Measurement, bool) {
+	// Values are sorted; find the last one at or before t
+	i := sort.Search(len(s.Values), func(i int) bool { return s.Values[i].Time.After(t) }) - 1
+	if i < 0 {
+		return Measurement{}, false
+	}
+	m := s.Values[i]
+	end := m.Time.Add(s.Interval)
+	if s.Interval == 24*time.Hour {
+		end = m.Time.AddDate(0, 0, 1) // 23 or 25 hours on DST changes
+	}
+	return m, t.Before(end)
+}
+
+func newSeries(values []Measurement, interval time.Duration, provenance Provenance) *Series {
+	sort.Slice(values, func(i, j int) bool { return values[i].Time.Before(values[j].Time) })
+	return &Series{Values: values, Interval: interval, Provenance: provenance}
+}
+
+// HistoryProvider looks up the values of a field measured in [from, to). Sources that
+// publish per day are asked for whole local days, so the series may reach a little further.
+type HistoryProvider interface {
+	History(ctx context.Context, field HistoryField, from, to time.Time) (*Series, error)
+}
+
+// HistoryService gets water conditions from the GKD daily means and the HND level table,
+// and the weather from the Open-Meteo archive
+type HistoryService struct {
+	archive *OpenMeteoArchiveClient
+}
+
+func NewHistoryService(archive *OpenMeteoArchiveClient) *HistoryService {
+	return &HistoryService{archive: archive}
+}
+
+func (h *HistoryService) History(ctx context.Context, field HistoryField, from, to time.Time) (*Series, error) {
+	switch field {
+	case HistoryWaterTemperature:
+		return gkdHistory(ctx, gkdWaterTemperature, from, to)
+	case HistoryWaterFlow:
+		return gkdHistory(ctx, gkdWaterFlow, from, to)
+	case HistoryWaterLevel:
+		return hndHistory(ctx, from, to)
+	case HistoryAirTemperature, HistoryWeatherCondition:
+		temperature, weatherCode, err := h.archive.Hourly(ctx, from, to)
+		if field == HistoryAirTemperature {
+			return temperature, err
+		}
+		return weatherCode, err
+	default:
+		return nil, fmt.Errorf("no history for %q", field)
+	}
+}
+
+// gkdHistory downloads the daily means of a GKD series
+func gkdHistory(ctx context.Context, series gkdSeries, from, to time.Time) (*Series, error) {
+	ctx, cancel := context.WithTimeout(ctx, gkdTimeout)
+	defer cancel()
+
+	records, err := downloadGKD(ctx, series, from, to)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseGKDSeries(records)
+	if err != nil {
+		return nil, err
+	}
+	return newSeries(values, 24*time.Hour, Provenance{
+		Source:    SourceGKD,
+		StationID: StationHimmelreichbruecke,
+		FetchedAt: time.Now(),
+	}), nil
+}
+
+// hndHistory reads the levels from the HND table. The table only covers the last days,
+// so older times are simply not in the series.
+func hndHistory(ctx context.Context, from, to time.Time) (*Series, error) {
+	history, err := ScrapeWaterLevelHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	levels := history.Between(from, to)
+	values := make([]Measurement, len(levels))
+	for i, level := range levels {
+		values[i] = Measurement{Time: level.Time, Value: level.Value}
+	}
+	provenance := history.Provenance
+	provenance.ObservedAt = time.Time{}
+	// HND lists a value every 15 minutes, but has gaps; an hour still describes the wave
+	return newSeries(values, time.Hour, provenance), nil
+}
+
+// parseGKDSeries reads the daily means of a GKD CSV; days without a value are skipped
+func parseGKDSeries(rows [][]string) ([]Measurement, error) {
+	if len(rows) < 1 {
+		return nil, fmt.Errorf("no data in CSV")
+	}
+	values := []Measurement{}
+	for _, row := range rows[1:] {
+		if len(row) < 2 || strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		raw := strings.ReplaceAll(strings.TrimSpace(row[1]), ",", ".")
+		var value float64
+		if _, err := fmt.Sscanf(raw, "%f", &value); err != nil {
+			continue // "---" marks a missing value
+		}
+		day, err := parseGKDDate(strings.TrimSpace(row[0]))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, Measurement{Time: day, Value: value})
+	}
+	return values, nil
+}
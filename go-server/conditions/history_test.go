@@ -0,0 +1,124 @@
+package conditions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseGKDSeriesFromFixture(t *testing.T) {
+	content, err := os.ReadFile("testdata/gkd_abfluss.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := parseGKDCSV(content)
+	if err != nil {
+		t.Fatalf("parseGKDCSV failed: %v", err)
+	}
+	values, err := parseGKDSeries(records)
+	if err != nil {
+		t.Fatalf("parseGKDSeries failed: %v", err)
+	}
+	t.Logf("%v", values)
+
+	if len(values) != 3 {
+		t.Fatalf("expected the day without a value to be skipped, got %v", values)
+	}
+	if want := time.Date(2025, 4, 1, 0, 0, 0, 0, munich); values[2].Value != 10.4 || !values[2].Time.Equal(want) {
+		t.Errorf("got %v, want 10.4 at %s", values[2], want)
+	}
+}
+
+func TestSeriesAtDailyMeanCoversLocalDay(t *testing.T) {
+	// 30.03.2025 is 23 hours long
+	series := newSeries([]Measurement{
+		{Time: time.Date(2025, 3, 31, 0, 0, 0, 0, munich), Value: 9.5},
+		{Time: time.Date(2025, 3, 30, 0, 0, 0, 0, munich), Value: 9.1},
+	}, 24*time.Hour, Provenance{Source: SourceGKD})
+
+	cases := []struct {
+		at    time.Time
+		value float64
+		ok    bool
+	}{
+		{time.Date(2025, 3, 29, 23, 59, 0, 0, munich), 0, false},
+		{time.Date(2025, 3, 30, 23, 30, 0, 0, munich), 9.1, true},
+		{time.Date(2025, 3, 31, 0, 0, 0, 0, munich), 9.5, true},
+		{time.Date(2025, 3, 31, 23, 59, 0, 0, munich), 9.5, true},
+		{time.Date(2025, 4, 1, 0, 0, 0, 0, munich), 0, false},
+	}
+	for _, tc := range cases {
+		m, ok := series.At(tc.at)
+		if ok != tc.ok || (ok && m.Value != tc.value) {
+			t.Errorf("At(%s) = %v, %v; want %.1f, %v", tc.at, m, ok, tc.value, tc.ok)
+		}
+	}
+}
+
+func TestParseWaterLevelHistoryFromFixture(t *testing.T) {
+	page, err := os.Open("testdata/hnd_wasserstand.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer page.Close()
+
+	history, err := parseWaterLevelHistory(page)
+	if err != nil {
+		t.Fatalf("parseWaterLevelHistory failed: %v", err)
+	}
+	t.Logf("%v", history.Levels)
+
+	if want := time.Date(2025, 6, 17, 10, 0, 0, 0, munich); !history.ObservedAt.Equal(want) {
+		t.Errorf("expected the newest row to be observed at %s, got %s", want, history.ObservedAt)
+	}
+	at := time.Date(2025, 6, 17, 9, 50, 0, 0, munich)
+	levels := history.Between(at.Add(-time.Hour), at)
+	if len(levels) != 2 || levels[0].Value != 142.5 {
+		t.Errorf("expected comma decimals to be read and the gap to be skipped, got %v", levels)
+	}
+}
+
+func TestOpenMeteoArchiveFromFixture(t *testing.T) {
+	var received http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = *r
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "testdata/openmeteo_archive.json")
+	}))
+	t.Cleanup(server.Close)
+
+	day := time.Date(2025, 3, 30, 0, 0, 0, 0, munich)
+	history := NewHistoryService(NewOpenMeteoArchiveClient(server.URL, 48.137154, 11.576124))
+	temperature, err := history.History(context.Background(), HistoryAirTemperature, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	weatherCode, err := history.History(context.Background(), HistoryWeatherCondition, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+
+	query := received.URL.Query()
+	if query.Get("start_date") != "2025-03-30" || query.Get("end_date") != "2025-03-31" || query.Get("timeformat") != "unixtime" {
+		t.Errorf("unexpected query: %s", received.URL.RawQuery)
+	}
+
+	// 14:00 summer time is the 13th hour of the day the clocks go forward
+	afternoon := time.Date(2025, 3, 30, 14, 20, 0, 0, munich)
+	if m, ok := temperature.At(afternoon); !ok || m.Value != 10.5 {
+		t.Errorf("expected 10.5 °C at %s, got %v, %v", afternoon, m, ok)
+	}
+	if m, ok := weatherCode.At(afternoon); !ok || m.Value != 61 {
+		t.Errorf("expected rain at %s, got %v, %v", afternoon, m, ok)
+	}
+	// The last hour has no data yet
+	if m, ok := temperature.At(time.Date(2025, 3, 30, 23, 30, 0, 0, munich)); ok {
+		t.Errorf("expected no value for an hour the archive doesn't have yet, got %v", m)
+	}
+	if temperature.Source != SourceOpenMeteo {
+		t.Errorf("expected open-meteo provenance, got %+v", temperature.Provenance)
+	}
+}
@@ -0,0 +1,18 @@
+package conditions
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/logging"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/tracing"
+)
+
+// upstreamTimeout bounds a single outbound call. Callers add their own,
+// usually tighter, deadline through the request context.
+const upstreamTimeout = 10 * time.Second
+
+// gkdTimeout bounds the whole GKD token → poll → download → parse sequence
+const gkdTimeout = 60 * time.Second
+
+var httpClient = &http.Client{Timeout: upstreamTimeout, Transport: tracing.Transport(logging.Transport(nil))}
@@ -1,23 +1,35 @@
 package conditions
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // MockWaterService is a mock implementation of WaterDataProvider for testing.
 type MockWaterService struct{}
 
 // GetLatestWaterLevelAndFlow implements WaterDataProvider.
-func (m *MockWaterService) GetLatestWaterLevelAndFlow() (*WaterLevelAndFlow, error) {
+func (m *MockWaterService) GetLatestWaterLevelAndFlow(ctx context.Context) (*WaterLevelAndFlow, error) {
+	now := time.Now()
 	return &WaterLevelAndFlow{
-		Level:       143.0,
-		Flow:        9.5,
-		RequestDate: time.Now().Format(time.RFC3339),
+		Level:      143.0,
+		Flow:       9.5,
+		Provenance: Provenance{Source: SourcePegelAlarm, StationID: StationHimmelreichbruecke, ObservedAt: now, FetchedAt: now},
 	}, nil
 }
 
-func (m *MockWaterService) GetCachedWaterTemperature() (float64, error) {
-	return 16.5, nil
+func (m *MockWaterService) GetLatestWaterTemperature(ctx context.Context) (*WaterTemperature, error) {
+	now := time.Now()
+	return &WaterTemperature{
+		Value:      16.5,
+		Provenance: Provenance{Source: SourceGKD, StationID: StationHimmelreichbruecke, ObservedAt: now, FetchedAt: now},
+	}, nil
 }
 
-func (m *MockWaterService) GetLatestWaterTemperature() (float64, error) {
-	return 16.5, nil
+func (m *MockWaterService) GetHistoricalWaterLevels(ctx context.Context) (*WaterLevelHistory, error) {
+	now := time.Now()
+	return &WaterLevelHistory{
+		Levels:     []HistoricalWaterLevel{{Time: now, Value: 143.0}},
+		Provenance: Provenance{Source: SourceHND, StationID: StationHimmelreichbruecke, ObservedAt: now, FetchedAt: now},
+	}, nil
 }
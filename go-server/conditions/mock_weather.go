@@ -1,6 +1,8 @@
 package conditions
 
-func GetCurrentWeather() (*WeatherData, error) {
+import "context"
+
+func GetCurrentWeather(ctx context.Context) (*WeatherData, error) {
 	// Mock data
 	return &WeatherData{
 		Temp:      22,
@@ -8,6 +10,6 @@ func GetCurrentWeather() (*WeatherData, error) {
 	}, nil
 }
 
-func GetLatestWaterTemperature() (float64, error) {
-	return 18.5, nil
+func GetLatestWaterTemperature(ctx context.Context) (*WaterTemperature, error) {
+	return &WaterTemperature{Value: 18.5}, nil
 }
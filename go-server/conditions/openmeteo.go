@@ -0,0 +1,282 @@
+package conditions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	openMeteoCurrentFields = []string{"temperature_2m", "apparent_temperature", "precipitation", "weather_code", "cloud_cover", "wind_speed_10m", "wind_gusts_10m", "uv_index", "is_day"}
+	openMeteoHourlyFields  = []string{"temperature_2m", "apparent_temperature", "precipitation_probability", "precipitation", "weather_code", "cloud_cover", "wind_speed_10m", "wind_gusts_10m", "uv_index"}
+	openMeteoDailyFields   = []string{"temperature_2m_max", "temperature_2m_min", "precipitation_sum", "precipitation_probability_max", "wind_speed_10m_max", "wind_gusts_10m_max", "uv_index_max", "sunrise", "sunset"}
+)
+
+// OpenMeteoClient fetches current conditions and forecasts for one location.
+// Values are always requested in metric units; use WeatherForecast.InUnits to convert.
+type OpenMeteoClient struct {
+	baseURL      string
+	latitude     float64
+	longitude    float64
+	forecastDays int
+	timezone     string
+}
+
+func NewOpenMeteoClient(baseURL string, latitude, longitude float64, forecastDays int) *OpenMeteoClient {
+	return &OpenMeteoClient{
+		baseURL:      baseURL,
+		latitude:     latitude,
+		longitude:    longitude,
+		forecastDays: forecastDays,
+		timezone:     spotLocation.String(), // daily values (sunrise, max temp) follow local days
+	}
+}
+
+type CurrentConditions struct {
+	Time                time.Time `json:"time"`
+	Temperature         float64   `json:"temperature"`
+	ApparentTemperature float64   `json:"apparent_temperature"`
+	Precipitation       float64   `json:"precipitation"`
+	WeatherCode         int       `json:"weather_code"`
+	CloudCover          int       `json:"cloud_cover"`
+	WindSpeed           float64   `json:"wind_speed"`
+	WindGusts           float64   `json:"wind_gusts"`
+	UVIndex             float64   `json:"uv_index"`
+	IsDay               bool      `json:"is_day"`
+}
+
+type HourlyForecast struct {
+	Time                     time.Time `json:"time"`
+	Temperature              float64   `json:"temperature"`
+	ApparentTemperature      float64   `json:"apparent_temperature"`
+	PrecipitationProbability int       `json:"precipitation_probability"`
+	Precipitation            float64   `json:"precipitation"`
+	WeatherCode              int       `json:"weather_code"`
+	CloudCover               int       `json:"cloud_cover"`
+	WindSpeed                float64   `json:"wind_speed"`
+	WindGusts                float64   `json:"wind_gusts"`
+	UVIndex                  float64   `json:"uv_index"`
+}
+
+type DailyForecast struct {
+	Date                        time.Time `json:"date"`
+	TemperatureMax              float64   `json:"temperature_max"`
+	TemperatureMin              float64   `json:"temperature_min"`
+	PrecipitationSum            float64   `json:"precipitation_sum"`
+	PrecipitationProbabilityMax int       `json:"precipitation_probability_max"`
+	WindSpeedMax                float64   `json:"wind_speed_max"`
+	WindGustsMax                float64   `json:"wind_gusts_max"`
+	UVIndexMax                  float64   `json:"uv_index_max"`
+	Sunrise                     time.Time `json:"sunrise"`
+	Sunset                      time.Time `json:"sunset"`
+}
+
+type WeatherForecast struct {
+	Latitude  float64           `json:"latitude"`
+	Longitude float64           `json:"longitude"`
+	Units     ForecastUnits     `json:"units"`
+	Current   CurrentConditions `json:"current"`
+	Hourly    []HourlyForecast  `json:"hourly"`
+	Daily     []DailyForecast   `json:"daily"`
+	Provenance
+
+	location *time.Location
+}
+
+type openMeteoResponse struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+	Current   struct {
+		Time                int64   `json:"time"`
+		Temperature         float64 `json:"temperature_2m"`
+		ApparentTemperature float64 `json:"apparent_temperature"`
+		Precipitation       float64 `json:"precipitation"`
+		WeatherCode         int     `json:"weather_code"`
+		CloudCover          int     `json:"cloud_cover"`
+		WindSpeed           float64 `json:"wind_speed_10m"`
+		WindGusts           float64 `json:"wind_gusts_10m"`
+		UVIndex             float64 `json:"uv_index"`
+		IsDay               int     `json:"is_day"`
+	} `json:"current"`
+	Hourly struct {
+		Time                     []int64   `json:"time"`
+		Temperature              []float64 `json:"temperature_2m"`
+		ApparentTemperature      []float64 `json:"apparent_temperature"`
+		PrecipitationProbability []int     `json:"precipitation_probability"`
+		Precipitation            []float64 `json:"precipitation"`
+		WeatherCode              []int     `json:"weather_code"`
+		CloudCover               []int     `json:"cloud_cover"`
+		WindSpeed                []float64 `json:"wind_speed_10m"`
+		WindGusts                []float64 `json:"wind_gusts_10m"`
+		UVIndex                  []float64 `json:"uv_index"`
+	} `json:"hourly"`
+	Daily struct {
+		Time                        []int64   `json:"time"`
+		TemperatureMax              []float64 `json:"temperature_2m_max"`
+		TemperatureMin              []float64 `json:"temperature_2m_min"`
+		PrecipitationSum            []float64 `json:"precipitation_sum"`
+		PrecipitationProbabilityMax []int     `json:"precipitation_probability_max"`
+		WindSpeedMax                []float64 `json:"wind_speed_10m_max"`
+		WindGustsMax                []float64 `json:"wind_gusts_10m_max"`
+		UVIndexMax                  []float64 `json:"uv_index_max"`
+		Sunrise                     []int64   `json:"sunrise"`
+		Sunset                      []int64   `json:"sunset"`
+	} `json:"daily"`
+}
+
+// Forecast fetches current conditions plus hourly and daily forecasts
+func (c *OpenMeteoClient) Forecast(ctx context.Context) (*WeatherForecast, error) {
+	query := url.Values{
+		"latitude":      {strconv.FormatFloat(c.latitude, 'f', -1, 64)},
+		"longitude":     {strconv.FormatFloat(c.longitude, 'f', -1, 64)},
+		"current":       {strings.Join(openMeteoCurrentFields, ",")},
+		"hourly":        {strings.Join(openMeteoHourlyFields, ",")},
+		"daily":         {strings.Join(openMeteoDailyFields, ",")},
+		"timezone":      {c.timezone},
+		"timeformat":    {"unixtime"},
+		"forecast_days": {strconv.Itoa(c.forecastDays)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch open-meteo forecast: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("open-meteo returned non-200 status: %d", resp.StatusCode)
+	}
+
+	var apiResp openMeteoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode open-meteo response: %w", err)
+	}
+
+	return apiResp.toForecast(time.Now()), nil
+}
+
+func (r *openMeteoResponse) toForecast(fetchedAt time.Time) *WeatherForecast {
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		loc = spotLocation
+	}
+	at := func(unix int64) time.Time { return time.Unix(unix, 0).In(loc) }
+
+	f := &WeatherForecast{
+		Latitude:  r.Latitude,
+		Longitude: r.Longitude,
+		Units:     MetricUnits,
+		Current: CurrentConditions{
+			Time:                at(r.Current.Time),
+			Temperature:         r.Current.Temperature,
+			ApparentTemperature: r.Current.ApparentTemperature,
+			Precipitation:       r.Current.Precipitation,
+			WeatherCode:         r.Current.WeatherCode,
+			CloudCover:          r.Current.CloudCover,
+			WindSpeed:           r.Current.WindSpeed,
+			WindGusts:           r.Current.WindGusts,
+			UVIndex:             r.Current.UVIndex,
+			IsDay:               r.Current.IsDay == 1,
+		},
+		Hourly: make([]HourlyForecast, len(r.Hourly.Time)),
+		Daily:  make([]DailyForecast, len(r.Daily.Time)),
+		Provenance: Provenance{
+			Source:     SourceOpenMeteo,
+			ObservedAt: at(r.Current.Time),
+			FetchedAt:  fetchedAt,
+		},
+		location: loc,
+	}
+
+	h := r.Hourly
+	for i, t := range h.Time {
+		f.Hourly[i] = HourlyForecast{
+			Time:                     at(t),
+			Temperature:              valueAt(h.Temperature, i),
+			ApparentTemperature:      valueAt(h.ApparentTemperature, i),
+			PrecipitationProbability: valueAt(h.PrecipitationProbability, i),
+			Precipitation:            valueAt(h.Precipitation, i),
+			WeatherCode:              valueAt(h.WeatherCode, i),
+			CloudCover:               valueAt(h.CloudCover, i),
+			WindSpeed:                valueAt(h.WindSpeed, i),
+			WindGusts:                valueAt(h.WindGusts, i),
+			UVIndex:                  valueAt(h.UVIndex, i),
+		}
+	}
+
+	d := r.Daily
+	for i, t := range d.Time {
+		f.Daily[i] = DailyForecast{
+			Date:                        at(t),
+			TemperatureMax:              valueAt(d.TemperatureMax, i),
+			TemperatureMin:              valueAt(d.TemperatureMin, i),
+			PrecipitationSum:            valueAt(d.PrecipitationSum, i),
+			PrecipitationProbabilityMax: valueAt(d.PrecipitationProbabilityMax, i),
+			WindSpeedMax:                valueAt(d.WindSpeedMax, i),
+			WindGustsMax:                valueAt(d.WindGustsMax, i),
+			UVIndexMax:                  valueAt(d.UVIndexMax, i),
+			Sunrise:                     at(valueAt(d.Sunrise, i)),
+			Sunset:                      at(valueAt(d.Sunset, i)),
+		}
+	}
+
+	return f
+}
+
+// valueAt tolerates Open-Meteo arrays that are shorter than the time axis
+func valueAt[T any](values []T, i int) T {
+	var zero T
+	if i >= len(values) {
+		return zero
+	}
+	return values[i]
+}
+
+// CurrentWeather reduces the forecast to the current temperature and WMO code
+func (f *WeatherForecast) CurrentWeather() *WeatherData {
+	return &WeatherData{
+		Temp:       f.Current.Temperature,
+		Condition:  f.Current.WeatherCode,
+		Provenance: f.Provenance,
+	}
+}
+
+// HourAt returns the hourly forecast covering t
+func (f *WeatherForecast) HourAt(t time.Time) (HourlyForecast, bool) {
+	for _, h := range f.Hourly {
+		if !t.Before(h.Time) && t.Before(h.Time.Add(time.Hour)) {
+			return h, true
+		}
+	}
+	return HourlyForecast{}, false
+}
+
+// TodayAt returns the forecast for the given hour of the current local day
+func (f *WeatherForecast) TodayAt(now time.Time, hour int) (HourlyForecast, bool) {
+	loc := f.location
+	if loc == nil {
+		loc = spotLocation
+	}
+	local := now.In(loc)
+	return f.HourAt(time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, loc))
+}
+
+// DayOf returns the daily forecast for the local day containing t
+func (f *WeatherForecast) DayOf(t time.Time) (DailyForecast, bool) {
+	for _, d := range f.Daily {
+		if !t.Before(d.Date) && t.Before(d.Date.AddDate(0, 0, 1)) {
+			return d, true
+		}
+	}
+	return DailyForecast{}, false
+}
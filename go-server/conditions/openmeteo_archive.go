@@ -0,0 +1,87 @@
+package conditions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// OpenMeteoArchiveClient fetches measured (reanalysed) hourly weather of past days for one location
+type OpenMeteoArchiveClient struct {
+	baseURL   string
+	latitude  float64
+	longitude float64
+	timezone  string
+}
+
+func NewOpenMeteoArchiveClient(baseURL string, latitude, longitude float64) *OpenMeteoArchiveClient {
+	return &OpenMeteoArchiveClient{
+		baseURL:   baseURL,
+		latitude:  latitude,
+		longitude: longitude,
+		timezone:  spotLocation.String(), // start and end dates are local days
+	}
+}
+
+type openMeteoArchiveResponse struct {
+	Hourly struct {
+		Time        []int64    `json:"time"`
+		Temperature []*float64 `json:"temperature_2m"`
+		WeatherCode []*float64 `json:"weather_code"`
+	} `json:"hourly"`
+}
+
+// Hourly fetches the air temperature and WMO weather code of every hour of the local days
+// from from to to. The archive lags a few days behind; hours it has no data for yet are left out.
+func (c *OpenMeteoArchiveClient) Hourly(ctx context.Context, from, to time.Time) (temperature, weatherCode *Series, err error) {
+	query := url.Values{
+		"latitude":   {strconv.FormatFloat(c.latitude, 'f', -1, 64)},
+		"longitude":  {strconv.FormatFloat(c.longitude, 'f', -1, 64)},
+		"start_date": {from.In(spotLocation).Format(time.DateOnly)},
+		"end_date":   {to.In(spotLocation).Format(time.DateOnly)},
+		"hourly":     {"temperature_2m,weather_code"},
+		"timezone":   {c.timezone},
+		"timeformat": {"unixtime"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch open-meteo archive: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("open-meteo archive returned non-200 status: %d", resp.StatusCode)
+	}
+
+	var apiResp openMeteoArchiveResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode open-meteo archive response: %w", err)
+	}
+
+	temperature, weatherCode = apiResp.toSeries(time.Now())
+	return temperature, weatherCode, nil
+}
+
+func (r *openMeteoArchiveResponse) toSeries(fetchedAt time.Time) (temperature, weatherCode *Series) {
+	provenance := Provenance{Source: SourceOpenMeteo, FetchedAt: fetchedAt}
+	var temperatures, codes []Measurement
+	for i, unix := range r.Hourly.Time {
+		at := time.Unix(unix, 0).In(spotLocation)
+		if v := valueAt(r.Hourly.Temperature, i); v != nil {
+			temperatures = append(temperatures, Measurement{Time: at, Value: *v})
+		}
+		if v := valueAt(r.Hourly.WeatherCode, i); v != nil {
+			codes = append(codes, Measurement{Time: at, Value: *v})
+		}
+	}
+	return newSeries(temperatures, time.Hour, provenance), newSeries(codes, time.Hour, provenance)
+}
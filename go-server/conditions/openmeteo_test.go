@@ -0,0 +1,93 @@
+package conditions
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newOpenMeteoFixtureServer serves a recorded Open-Meteo response so tests run offline
+func newOpenMeteoFixtureServer(t *testing.T) (*httptest.Server, *http.Request) {
+	t.Helper()
+	var received http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = *r
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "testdata/openmeteo_forecast.json")
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestOpenMeteoForecastFromFixture(t *testing.T) {
+	server, received := newOpenMeteoFixtureServer(t)
+	client := NewOpenMeteoClient(server.URL, 48.137154, 11.576124, 2)
+
+	forecast, err := client.Forecast(context.Background())
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+
+	if got := received.URL.Query().Get("timeformat"); got != "unixtime" {
+		t.Errorf("expected unixtime timestamps to be requested, got %q", got)
+	}
+	if got := received.URL.Query().Get("latitude"); got != "48.137154" {
+		t.Errorf("expected configured latitude, got %q", got)
+	}
+
+	if forecast.Current.Temperature != 21.3 || forecast.Current.WeatherCode != 1 || !forecast.Current.IsDay {
+		t.Errorf("unexpected current conditions: %+v", forecast.Current)
+	}
+	if len(forecast.Hourly) != 48 || len(forecast.Daily) != 2 {
+		t.Fatalf("expected 48 hourly and 2 daily entries, got %d/%d", len(forecast.Hourly), len(forecast.Daily))
+	}
+	if forecast.Source != SourceOpenMeteo || forecast.ObservedAt.IsZero() {
+		t.Errorf("missing provenance: %+v", forecast.Provenance)
+	}
+
+	rainy, ok := forecast.HourAt(time.Date(2025, 6, 6, 15, 30, 0, 0, munich))
+	if !ok {
+		t.Fatal("expected an hourly entry for 6 June 15:30")
+	}
+	if rainy.WeatherCode != 61 || rainy.PrecipitationProbability != 70 {
+		t.Errorf("unexpected forecast for the rainy afternoon: %+v", rainy)
+	}
+
+	day, ok := forecast.DayOf(time.Date(2025, 6, 5, 12, 0, 0, 0, munich))
+	if !ok {
+		t.Fatal("expected a daily entry for 5 June")
+	}
+	if got := day.Sunrise.Format("15:04"); got != "05:14" {
+		t.Errorf("expected local sunrise 05:14, got %s", got)
+	}
+}
+
+func TestWeatherForecastInUnits(t *testing.T) {
+	server, _ := newOpenMeteoFixtureServer(t)
+	forecast, err := NewOpenMeteoClient(server.URL, 48.137154, 11.576124, 2).Forecast(context.Background())
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+
+	converted, err := forecast.InUnits(ForecastUnits{Temperature: "fahrenheit", WindSpeed: "ms", Precipitation: "inch"})
+	if err != nil {
+		t.Fatalf("InUnits failed: %v", err)
+	}
+
+	if math.Abs(converted.Current.Temperature-70.34) > 0.01 {
+		t.Errorf("expected 21.3°C → 70.34°F, got %.2f", converted.Current.Temperature)
+	}
+	if math.Abs(converted.Daily[1].PrecipitationSum-9.0/25.4) > 0.001 {
+		t.Errorf("expected precipitation in inches, got %.3f", converted.Daily[1].PrecipitationSum)
+	}
+	if forecast.Current.Temperature != 21.3 {
+		t.Error("InUnits must not modify the cached metric forecast")
+	}
+
+	if _, err := forecast.InUnits(ForecastUnits{Temperature: "kelvin", WindSpeed: "kmh", Precipitation: "mm"}); err == nil {
+		t.Error("expected unsupported unit to be rejected")
+	}
+}
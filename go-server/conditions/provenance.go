@@ -0,0 +1,95 @@
+package conditions
+
+import (
+	"time"
+	_ "time/tzdata" // upstream timestamps are Munich local time; don't rely on the container's zoneinfo
+)
+
+// Upstream sources
+const (
+	SourceOpenMeteo  = "open-meteo"
+	SourceGKD        = "gkd"
+	SourcePegelAlarm = "pegelalarm"
+	SourceHND        = "hnd"
+)
+
+// StationHimmelreichbruecke is the gauge closest to the Eisbach wave (Isar, München Himmelreichbrücke)
+const StationHimmelreichbruecke = "16515005"
+
+// Units used in readings
+const (
+	UnitCelsius        = "°C"
+	UnitCentimeters    = "cm"
+	UnitCubicMetersSec = "m³/s"
+	UnitWMOCode        = "wmo"
+)
+
+// maxObservationAge is how old an observation may get before it counts as stale,
+// derived from how often each source publishes
+var maxObservationAge = map[string]time.Duration{
+	SourceOpenMeteo:  time.Hour,
+	SourceGKD:        48 * time.Hour, // daily mean, published the next day
+	SourcePegelAlarm: time.Hour,
+	SourceHND:        2 * time.Hour,
+}
+
+var munich = mustLoadLocation("Europe/Berlin")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Provenance describes where a value came from and when
+type Provenance struct {
+	Source     string    `json:"source"`
+	StationID  string    `json:"station_id,omitempty"`
+	ObservedAt time.Time `json:"observed_at"` // when the upstream measured the value
+	FetchedAt  time.Time `json:"fetched_at"`  // when we fetched it from the upstream
+}
+
+// Stale reports whether the observation is older than its source normally allows
+func (p Provenance) Stale(now time.Time) bool {
+	maxAge, ok := maxObservationAge[p.Source]
+	if !ok || p.ObservedAt.IsZero() {
+		return false
+	}
+	return now.Sub(p.ObservedAt) > maxAge
+}
+
+// Covers reports whether the observation describes the conditions at t: it was made no
+// further from t than its source's publishing interval allows
+func (p Provenance) Covers(t time.Time) bool {
+	maxAge, ok := maxObservationAge[p.Source]
+	if !ok || p.ObservedAt.IsZero() {
+		return false
+	}
+	d := p.ObservedAt.Sub(t)
+	return d <= maxAge && -d <= maxAge
+}
+
+// Reading is the envelope every conditions endpoint returns
+type Reading[T any] struct {
+	Value      T         `json:"value"`
+	Unit       string    `json:"unit"`
+	ObservedAt time.Time `json:"observed_at"`
+	FetchedAt  time.Time `json:"fetched_at"`
+	Source     string    `json:"source"`
+	StationID  string    `json:"station_id,omitempty"`
+	Stale      bool      `json:"stale"`
+}
+
+func NewReading[T any](value T, unit string, p Provenance) Reading[T] {
+	return Reading[T]{
+		Value:      value,
+		Unit:       unit,
+		ObservedAt: p.ObservedAt,
+		FetchedAt:  p.FetchedAt,
+		Source:     p.Source,
+		StationID:  p.StationID,
+		Stale:      p.Stale(time.Now()),
+	}
+}
@@ -3,4 +3,10 @@ package conditions
 type WeatherData struct {
 	Temp      float64 `json:"temp"`
 	Condition int     `json:"condition"` // Use numeric WMO codes
+	Provenance
+}
+
+type WaterTemperature struct {
+	Value float64 `json:"value"`
+	Provenance
 }
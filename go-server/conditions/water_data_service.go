@@ -2,6 +2,7 @@ package conditions
 
 import (
 	"archive/zip"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -11,34 +12,33 @@ import (
 	"net/url"
 	"os"
 	"strings"
-	"sync"
 	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/logging"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/tracing"
 )
 
-type WaterDataService struct {
-	cacheLock     sync.Mutex
-	lastWaterTemp *float64
-	lastFetched   time.Time
-	cacheDuration time.Duration
-}
+// WaterDataService fetches straight from the upstreams; wrap it with
+// NewCachedWaterService to cache and protect them.
+type WaterDataService struct{}
 
 func NewWaterService() *WaterDataService {
-	return &WaterDataService{
-		cacheDuration: 60 * time.Minute,
-	}
+	return &WaterDataService{}
 }
 
 type WaterLevelAndFlow struct {
-	Level       float64
-	Flow        float64
-	RequestDate string
+	Level float64
+	Flow  float64
+	Provenance
 }
 
 type PegelAlarmResponse struct {
 	Payload struct {
 		Stations []struct {
-			Data []struct {
+			CommonID string `json:"commonid"`
+			Data     []struct {
 				RequestDate string  `json:"requestDate"`
+				SourceDate  string  `json:"sourceDate"` // when the gauge measured the value
 				Value       float64 `json:"value"`
 			} `json:"data"`
 		} `json:"stations"`
@@ -46,95 +46,133 @@ type PegelAlarmResponse struct {
 }
 
 // --- Interface
+// All methods honor ctx: when it is cancelled or its deadline passes they
+// return promptly with ctx.Err() (or the last known value, if cached).
 type WaterDataProvider interface {
-	GetCachedWaterTemperature() (float64, error)
-	GetLatestWaterTemperature() (float64, error)
-	GetLatestWaterLevelAndFlow() (*WaterLevelAndFlow, error)
+	GetLatestWaterTemperature(ctx context.Context) (*WaterTemperature, error)
+	GetLatestWaterLevelAndFlow(ctx context.Context) (*WaterLevelAndFlow, error)
+	GetHistoricalWaterLevels(ctx context.Context) (*WaterLevelHistory, error)
 }
 
-// --- API Methods
+// --- Public Fetching Method ---
 
-func (ws *WaterDataService) GetCachedWaterTemperature() (float64, error) {
-	ws.cacheLock.Lock()
-	defer ws.cacheLock.Unlock()
+func (ws *WaterDataService) GetLatestWaterTemperature(ctx context.Context) (*WaterTemperature, error) {
+	ctx, cancel := context.WithTimeout(ctx, gkdTimeout)
+	defer cancel()
 
-	if ws.lastWaterTemp != nil && time.Since(ws.lastFetched) < ws.cacheDuration {
-		return *ws.lastWaterTemp, nil
+	// Last week of daily means; the newest row is yesterday's (or today's, once published)
+	today := time.Now().In(munich)
+	records, err := downloadGKD(ctx, gkdWaterTemperature, today.AddDate(0, 0, -7), today)
+	if err != nil {
+		return nil, err
 	}
 
-	temp, err := ws.GetLatestWaterTemperature()
+	temp, observedAt, err := parseLatestWaterTemperature(records)
 	if err != nil {
-		if ws.lastWaterTemp != nil {
-			return *ws.lastWaterTemp, nil
-		}
-		return 0, err
+		return nil, err
 	}
 
-	ws.lastWaterTemp = &temp
-	ws.lastFetched = time.Now()
-	return temp, nil
+	return &WaterTemperature{
+		Value: temp,
+		Provenance: Provenance{
+			Source:     SourceGKD,
+			StationID:  StationHimmelreichbruecke,
+			ObservedAt: observedAt,
+			FetchedAt:  time.Now(),
+		},
+	}, nil
 }
 
-// --- Public Fetching Method ---
+// --- Internal Helpers ---
 
-func (ws *WaterDataService) GetLatestWaterTemperature() (float64, error) {
+// gkdSeries is a GKD download: the station page to start the session on and the parameter to export
+type gkdSeries struct {
+	page      string
+	parameter string
+}
+
+var (
+	gkdWaterTemperature = gkdSeries{
+		page:      "https://www.gkd.bayern.de/de/fluesse/wassertemperatur/kelheim/muenchen-himmelreichbruecke-16515005/download",
+		parameter: "fluesse.wassertemperatur",
+	}
+	gkdWaterFlow = gkdSeries{
+		page:      "https://www.gkd.bayern.de/de/fluesse/abfluss/kelheim/muenchen-himmelreichbruecke-16515005/download",
+		parameter: "fluesse.abfluss",
+	}
+)
+
+// downloadGKD exports the daily means of series between the local days begin and end
+// and returns the rows of the CSV, starting with its header
+func downloadGKD(ctx context.Context, series gkdSeries, begin, end time.Time) ([][]string, error) {
 	client, err := createHTTPClient()
 	if err != nil {
-		return 0, fmt.Errorf("creating HTTP client: %w", err)
+		return nil, fmt.Errorf("creating HTTP client: %w", err)
 	}
 
-	token, err := requestDownloadToken(client)
+	// The GKD download is a three step dance; a span per step shows where the time goes
+	tokenCtx, span := tracing.Start(ctx, "gkd.request_token")
+	token, err := requestDownloadToken(tokenCtx, client, series, begin, end)
+	tracing.End(span, err)
 	if err != nil {
-		return 0, fmt.Errorf("getting token: %w", err)
+		return nil, fmt.Errorf("getting token: %w", err)
 	}
 
 	downloadURL := fmt.Sprintf("https://www.gkd.bayern.de/de/downloadcenter/download?token=%s&dl=1", token)
 
-	zipPath, err := pollAndDownloadZip(client, downloadURL)
+	pollCtx, span := tracing.Start(ctx, "gkd.poll_and_download")
+	zipPath, err := pollAndDownloadZip(pollCtx, client, downloadURL)
+	tracing.End(span, err)
+	if zipPath != "" {
+		defer os.Remove(zipPath)
+	}
 	if err != nil {
-		return 0, fmt.Errorf("downloading zip: %w", err)
+		return nil, fmt.Errorf("downloading zip: %w", err)
 	}
-	defer os.Remove(zipPath)
 
+	_, span = tracing.Start(ctx, "gkd.extract_csv")
 	records, err := extractCSV(zipPath)
+	tracing.End(span, err)
 	if err != nil {
-		return 0, fmt.Errorf("parsing CSV: %w", err)
+		return nil, fmt.Errorf("parsing CSV: %w", err)
 	}
-
-	return parseLatestWaterTemperature(records)
+	return records, nil
 }
 
-// --- Internal Helpers ---
-
 func createHTTPClient() (*http.Client, error) {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		return nil, err
 	}
-	return &http.Client{Jar: jar}, nil
+	return &http.Client{Jar: jar, Timeout: upstreamTimeout, Transport: tracing.Transport(logging.Transport(nil))}, nil
 }
 
-func requestDownloadToken(client *http.Client) (string, error) {
-	page := "https://www.gkd.bayern.de/de/fluesse/wassertemperatur/kelheim/muenchen-himmelreichbruecke-16515005/download"
+func requestDownloadToken(ctx context.Context, client *http.Client, series gkdSeries, begin, end time.Time) (string, error) {
+	page := series.page
 
 	// Load page first (important for cookies/session)
-	_, err := client.Get(page)
+	pageReq, err := http.NewRequestWithContext(ctx, http.MethodGet, page, nil)
 	if err != nil {
 		return "", err
 	}
+	pageResp, err := client.Do(pageReq)
+	if err != nil {
+		return "", err
+	}
+	pageResp.Body.Close()
 
 	form := url.Values{
 		"zr":       {"monat"},
-		"beginn":   {"01.04.2025"},
-		"ende":     {"05.04.2025"},
+		"beginn":   {begin.In(munich).Format("02.01.2006")},
+		"ende":     {end.In(munich).Format("02.01.2006")},
 		"email":    {"test@test.de"},
 		"geprueft": {"0"},
 		"wertart":  {"tmw"},
 		"f":        {""},
-		"t":        {`{"16515005":["fluesse.wassertemperatur"]}`},
+		"t":        {`{"` + StationHimmelreichbruecke + `":["` + series.parameter + `"]}`},
 	}
 
-	req, err := http.NewRequest("POST", "https://www.gkd.bayern.de/de/downloadcenter/enqueue_download", strings.NewReader(form.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://www.gkd.bayern.de/de/downloadcenter/enqueue_download", strings.NewReader(form.Encode()))
 	if err != nil {
 		return "", err
 	}
@@ -167,36 +205,54 @@ func requestDownloadToken(client *http.Client) (string, error) {
 	return strings.TrimSuffix(strings.TrimSpace(tokenRaw[:tokenEnd]), `\`), nil
 }
 
-func pollAndDownloadZip(client *http.Client, downloadURL string) (string, error) {
+func pollAndDownloadZip(ctx context.Context, client *http.Client, downloadURL string) (string, error) {
 	for i := 0; i < 15; i++ {
-		head, _ := client.Head(downloadURL)
-		if head != nil &&
-			head.StatusCode == 200 &&
-			head.ContentLength > 0 &&
-			strings.Contains(head.Header.Get("Content-Type"), "zip") {
+		if downloadReady(ctx, client, downloadURL) {
 			goto Ready
 		}
-		time.Sleep(3 * time.Second)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(3 * time.Second):
+		}
 	}
 	return "", fmt.Errorf("download not ready")
 
 Ready:
-	resp, err := client.Get(downloadURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	path := os.TempDir() + "/data.zip"
-
-	out, err := os.Create(path)
+	// A file per download: the poller and a backfill may download at the same time
+	out, err := os.CreateTemp("", "gkd-*.zip")
 	if err != nil {
 		return "", err
 	}
 	defer out.Close()
 
 	_, err = io.Copy(out, resp.Body)
-	return path, err
+	return out.Name(), err
+}
+
+func downloadReady(ctx context.Context, client *http.Client, downloadURL string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, downloadURL, nil)
+	if err != nil {
+		return false
+	}
+	head, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	head.Body.Close()
+	return head.StatusCode == 200 &&
+		head.ContentLength > 0 &&
+		strings.Contains(head.Header.Get("Content-Type"), "zip")
 }
 
 func extractCSV(zipPath string) ([][]string, error) {
@@ -211,37 +267,65 @@ func extractCSV(zipPath string) ([][]string, error) {
 			rc, _ := f.Open()
 			defer rc.Close()
 			content, _ := io.ReadAll(rc)
-			lines := strings.Split(string(content), "\n")
-
-			for i, line := range lines {
-				if strings.HasPrefix(strings.TrimSpace(line), "Datum") {
-					reader := csv.NewReader(strings.NewReader(strings.Join(lines[i:], "\n")))
-					reader.Comma = ';'
-					reader.FieldsPerRecord = -1
-					return reader.ReadAll()
-				}
+			if records, err := parseGKDCSV(content); err == nil {
+				return records, nil
 			}
 		}
 	}
 	return nil, fmt.Errorf("no valid CSV found")
 }
 
-func parseLatestWaterTemperature(rows [][]string) (float64, error) {
+// parseGKDCSV skips the station description GKD puts above the table, which starts at "Datum"
+func parseGKDCSV(content []byte) ([][]string, error) {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "Datum") {
+			reader := csv.NewReader(strings.NewReader(strings.Join(lines[i:], "\n")))
+			reader.Comma = ';'
+			reader.FieldsPerRecord = -1
+			return reader.ReadAll()
+		}
+	}
+	return nil, fmt.Errorf("no table in CSV")
+}
+
+// parseLatestWaterTemperature returns the newest daily mean and the day it was measured
+func parseLatestWaterTemperature(rows [][]string) (float64, time.Time, error) {
 	if len(rows) < 2 {
-		return 0, fmt.Errorf("no data in CSV")
+		return 0, time.Time{}, fmt.Errorf("no data in CSV")
 	}
 	last := rows[len(rows)-1]
+	if len(last) < 2 {
+		return 0, time.Time{}, fmt.Errorf("malformed CSV row: %v", last)
+	}
 	tempStr := strings.ReplaceAll(last[1], ",", ".")
 	var temp float64
 	fmt.Sscanf(tempStr, "%f", &temp)
-	return temp, nil
+
+	observedAt, err := parseGKDDate(strings.TrimSpace(last[0]))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return temp, observedAt, nil
+}
+
+func parseGKDDate(raw string) (time.Time, error) {
+	for _, layout := range []string{"02.01.2006 15:04", "02.01.2006"} {
+		if t, err := time.ParseInLocation(layout, raw, munich); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unexpected GKD date %q", raw)
 }
 
 // --- PegelAlarm API Fetching ---
-func (ws *WaterDataService) fetchPegelAlarmData() (*PegelAlarmResponse, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+func (ws *WaterDataService) fetchPegelAlarmData(ctx context.Context) (*PegelAlarmResponse, error) {
 	url := os.Getenv("PEGELALARM_API_URL")
-	res, err := client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build pegelalarm request: %w", err)
+	}
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch pegelalarm data: %w", err)
 	}
@@ -260,8 +344,8 @@ func (ws *WaterDataService) fetchPegelAlarmData() (*PegelAlarmResponse, error) {
 }
 
 // Fetches the latest water level and flow from PegelAlarm
-func (ws *WaterDataService) GetLatestWaterLevelAndFlow() (*WaterLevelAndFlow, error) {
-	data, err := ws.fetchPegelAlarmData() // ← Your PegelAlarm fetcher
+func (ws *WaterDataService) GetLatestWaterLevelAndFlow(ctx context.Context) (*WaterLevelAndFlow, error) {
+	data, err := ws.fetchPegelAlarmData(ctx) // ← Your PegelAlarm fetcher
 	if err != nil {
 		return nil, err
 	}
@@ -273,20 +357,33 @@ func (ws *WaterDataService) GetLatestWaterLevelAndFlow() (*WaterLevelAndFlow, er
 
 	level := stations[0].Data[0].Value
 	flow := stations[0].Data[1].Value
-	rawDate := stations[0].Data[0].RequestDate
+	rawDate := stations[0].Data[0].SourceDate
+	if rawDate == "" {
+		rawDate = stations[0].Data[0].RequestDate
+	}
 	parsedDate, err := time.Parse("02.01.2006T15:04:05-0700", rawDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse requestDate: %w", err)
+		return nil, fmt.Errorf("failed to parse measurement date: %w", err)
+	}
+
+	stationID := strings.TrimSuffix(stations[0].CommonID, "-de")
+	if stationID == "" {
+		stationID = StationHimmelreichbruecke
 	}
 
 	return &WaterLevelAndFlow{
-		Level:       level,
-		Flow:        flow,
-		RequestDate: parsedDate.Format(time.RFC3339), // Converts to "2025-04-17T22:43:04+02:00"
+		Level: level,
+		Flow:  flow,
+		Provenance: Provenance{
+			Source:     SourcePegelAlarm,
+			StationID:  stationID,
+			ObservedAt: parsedDate,
+			FetchedAt:  time.Now(),
+		},
 	}, nil
 
 }
 
-func (ws *WaterDataService) GetHistoricalWaterLevels() ([]HistoricalWaterLevel, error) {
-	return ScrapeWaterLevelHistory()
+func (ws *WaterDataService) GetHistoricalWaterLevels(ctx context.Context) (*WaterLevelHistory, error) {
+	return ScrapeWaterLevelHistory(ctx)
 }
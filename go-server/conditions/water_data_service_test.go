@@ -0,0 +1,53 @@
+package conditions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLatestWaterTemperatureUsesNewestRow(t *testing.T) {
+	rows := [][]string{
+		{"Datum", "Wassertemperatur [°C]"},
+		{"03.06.2025", "14,2"},
+		{"04.06.2025", "14,8"},
+	}
+
+	temp, observedAt, err := parseLatestWaterTemperature(rows)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	want := time.Date(2025, 6, 4, 0, 0, 0, 0, munich)
+	if temp != 14.8 || !observedAt.Equal(want) {
+		t.Errorf("got %.1f at %s, want 14.8 at %s", temp, observedAt, want)
+	}
+}
+
+func TestProvenanceStaleDependsOnSource(t *testing.T) {
+	now := time.Now()
+	daily := Provenance{Source: SourceGKD, ObservedAt: now.Add(-30 * time.Hour)}
+	gauge := Provenance{Source: SourcePegelAlarm, ObservedAt: now.Add(-30 * time.Hour)}
+
+	if daily.Stale(now) {
+		t.Error("a 30h old GKD daily mean should not be stale")
+	}
+	if !gauge.Stale(now) {
+		t.Error("a 30h old PegelAlarm reading should be stale")
+	}
+}
+
+func TestProvenanceCoversNearbyTimes(t *testing.T) {
+	at := time.Date(2025, 6, 21, 14, 0, 0, 0, munich)
+	daily := Provenance{Source: SourceGKD, ObservedAt: time.Date(2025, 6, 20, 0, 0, 0, 0, munich)}
+	gauge := Provenance{Source: SourcePegelAlarm, ObservedAt: at.Add(-90 * time.Minute)}
+
+	if !daily.Covers(at) {
+		t.Error("yesterday's GKD daily mean should cover the afternoon")
+	}
+	if gauge.Covers(at) || !gauge.Covers(at.Add(-time.Hour)) {
+		t.Error("a PegelAlarm reading should only cover the hour around it")
+	}
+	if (Provenance{Source: SourceOpenMeteo}).Covers(at) {
+		t.Error("a reading without observation time covers nothing")
+	}
+}
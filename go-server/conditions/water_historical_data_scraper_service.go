@@ -1,25 +1,61 @@
 package conditions
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
 // Represents a single row from the water level history table
 type HistoricalWaterLevel struct {
-	DateTime string
-	Value    float64
+	Time  time.Time `json:"timestamp"`
+	Value float64   `json:"value"`
+}
+
+// HistoricalWaterLevelCSVHeader is the column order of water level history exports
+var HistoricalWaterLevelCSVHeader = []string{"timestamp", "value"}
+
+// CSVRecord formats the level in the column order of HistoricalWaterLevelCSVHeader
+func (l HistoricalWaterLevel) CSVRecord() []string {
+	return []string{l.Time.Format(time.RFC3339), strconv.FormatFloat(l.Value, 'f', -1, 64)}
+}
+
+// WaterLevelHistory is the scraped table; ObservedAt is the newest row
+type WaterLevelHistory struct {
+	Levels []HistoricalWaterLevel
+	Provenance
+}
+
+// Between returns the levels measured in [from, to); zero bounds are open
+func (h *WaterLevelHistory) Between(from, to time.Time) []HistoricalWaterLevel {
+	if from.IsZero() && to.IsZero() {
+		return h.Levels
+	}
+	levels := []HistoricalWaterLevel{}
+	for _, level := range h.Levels {
+		if (from.IsZero() || !level.Time.Before(from)) && (to.IsZero() || level.Time.Before(to)) {
+			levels = append(levels, level)
+		}
+	}
+	return levels
 }
 
 // Scrapes historical water level values from the HND Bayern site
-func ScrapeWaterLevelHistory() ([]HistoricalWaterLevel, error) {
+func ScrapeWaterLevelHistory(ctx context.Context) (*WaterLevelHistory, error) {
 	url := os.Getenv("HND_BAYERN_URL")
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch page: %w", err)
 	}
@@ -31,27 +67,50 @@ func ScrapeWaterLevelHistory() ([]HistoricalWaterLevel, error) {
 		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	return parseWaterLevelHistory(resp.Body)
+}
+
+// parseWaterLevelHistory reads the level table of an HND station page
+func parseWaterLevelHistory(page io.Reader) (*WaterLevelHistory, error) {
+	doc, err := goquery.NewDocumentFromReader(page)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
-	var results []HistoricalWaterLevel
+	history := &WaterLevelHistory{
+		Levels: []HistoricalWaterLevel{},
+		Provenance: Provenance{
+			Source:    SourceHND,
+			StationID: StationHimmelreichbruecke,
+			FetchedAt: time.Now(),
+		},
+	}
 	doc.Find("table.tblsort tbody tr").Each(func(i int, s *goquery.Selection) {
 		cols := s.Find("td")
 		if cols.Length() >= 2 {
 			dateText := strings.TrimSpace(cols.Eq(0).Text())
 			valueText := strings.ReplaceAll(strings.TrimSpace(cols.Eq(1).Text()), ",", ".")
 
+			// HND lists Munich local time, e.g. "17.04.2025 22:45"
+			measuredAt, err := time.ParseInLocation("02.01.2006 15:04", dateText, munich)
+			if err != nil {
+				return
+			}
+
 			var value float64
-			fmt.Sscanf(valueText, "%f", &value)
+			if _, err := fmt.Sscanf(valueText, "%f", &value); err != nil {
+				return // "--" while the gauge is down
+			}
 
-			results = append(results, HistoricalWaterLevel{
-				DateTime: dateText,
-				Value:    value,
+			history.Levels = append(history.Levels, HistoricalWaterLevel{
+				Time:  measuredAt,
+				Value: value,
 			})
+			if measuredAt.After(history.ObservedAt) {
+				history.ObservedAt = measuredAt
+			}
 		}
 	})
 
-	return results, nil
+	return history, nil
 }
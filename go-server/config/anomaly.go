@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// AnomalyConfig sets how suspicious a surfer report must be to be flagged or quarantined
+type AnomalyConfig struct {
+	FlagScore       float64 // robust z-score from which a report is flagged for review
+	QuarantineScore float64 // ... and from which it is excluded until reviewed
+	MaxCount        int     // counts above this are always quarantined
+	MinSamples      int     // observations needed before a distribution is used for scoring
+}
+
+var Anomaly AnomalyConfig
+
+// LoadAnomalyConfig reads ANOMALY_FLAG_SCORE, ANOMALY_QUARANTINE_SCORE, ANOMALY_MAX_COUNT
+// and ANOMALY_MIN_SAMPLES
+func LoadAnomalyConfig() error {
+	cfg := AnomalyConfig{
+		FlagScore:       3.5,
+		QuarantineScore: 6,
+		MaxCount:        80,
+		MinSamples:      5,
+	}
+
+	if err := floatFromEnv("ANOMALY_FLAG_SCORE", &cfg.FlagScore); err != nil {
+		return err
+	}
+	if err := floatFromEnv("ANOMALY_QUARANTINE_SCORE", &cfg.QuarantineScore); err != nil {
+		return err
+	}
+	if err := intFromEnv("ANOMALY_MAX_COUNT", &cfg.MaxCount); err != nil {
+		return err
+	}
+	if err := intFromEnv("ANOMALY_MIN_SAMPLES", &cfg.MinSamples); err != nil {
+		return err
+	}
+	if cfg.FlagScore <= 0 || cfg.QuarantineScore < cfg.FlagScore {
+		return fmt.Errorf("ANOMALY_QUARANTINE_SCORE (%v) must be at least ANOMALY_FLAG_SCORE (%v) > 0", cfg.QuarantineScore, cfg.FlagScore)
+	}
+
+	Anomaly = cfg
+	return nil
+}
+
+func intFromEnv(key string, target *int) error {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return nil
+	}
+	i, err := strconv.Atoi(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	*target = i
+	return nil
+}
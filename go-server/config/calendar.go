@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pelletier/go-toml"
+)
+
+type SchoolHoliday struct {
+	Name  string
+	Start string // YYYY-MM-DD, inclusive
+	End   string // YYYY-MM-DD, inclusive
+}
+
+type CalendarConfig struct {
+	SchoolHolidays []SchoolHoliday `toml:"school_holidays"`
+}
+
+var Calendar CalendarConfig
+
+func LoadCalendarConfig() error {
+	path := os.Getenv("CALENDAR_CONFIG")
+	if path == "" {
+		path = "./config/calendar.toml" // fallback default
+	}
+
+	tree, err := toml.LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var cfg CalendarConfig
+	if err := tree.Unmarshal(&cfg); err != nil {
+		return err
+	}
+
+	for _, h := range cfg.SchoolHolidays {
+		start, err := time.Parse("2006-01-02", h.Start)
+		if err != nil {
+			return fmt.Errorf("school holiday %q: invalid start: %w", h.Name, err)
+		}
+		end, err := time.Parse("2006-01-02", h.End)
+		if err != nil {
+			return fmt.Errorf("school holiday %q: invalid end: %w", h.Name, err)
+		}
+		if end.Before(start) {
+			return fmt.Errorf("school holiday %q ends before it starts", h.Name)
+		}
+	}
+
+	Calendar = cfg
+	return nil
+}
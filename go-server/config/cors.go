@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig lists who may call the API from a browser or the app's web view
+type CORSConfig struct {
+	AllowedOrigins   []string // exact origins, "*" for any, or one wildcard like "https://*.example.com"
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// SecurityConfig controls the security headers sent with every response
+type SecurityConfig struct {
+	HSTSMaxAge time.Duration // 0 disables Strict-Transport-Security (e.g. plain http locally)
+}
+
+var (
+	CORS     CORSConfig
+	Security SecurityConfig
+)
+
+// LoadCORSConfig reads CORS_* and HSTS_MAX_AGE. The defaults allow the GitHub Pages PWA,
+// the Vite dev server and the Capacitor iOS (capacitor://localhost) and Android (https://localhost) apps.
+func LoadCORSConfig() error {
+	cors := CORSConfig{
+		AllowedOrigins: []string{"https://vr33ni.github.io", "http://localhost:5173", "capacitor://localhost", "https://localhost"},
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-Contributor-Token", "X-Request-ID"},
+		ExposedHeaders: []string{"Retry-After", "Age", "X-Cache", "X-Request-ID", "Deprecation", "Link", "ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+	security := SecurityConfig{HSTSMaxAge: 180 * 24 * time.Hour}
+
+	for key, target := range map[string]*[]string{
+		"CORS_ALLOWED_ORIGINS": &cors.AllowedOrigins,
+		"CORS_ALLOWED_METHODS": &cors.AllowedMethods,
+		"CORS_ALLOWED_HEADERS": &cors.AllowedHeaders,
+		"CORS_EXPOSED_HEADERS": &cors.ExposedHeaders,
+	} {
+		if raw, ok := os.LookupEnv(key); ok {
+			*target = splitList(raw)
+		}
+	}
+	if raw := os.Getenv("CORS_ALLOW_CREDENTIALS"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid CORS_ALLOW_CREDENTIALS %q: %w", raw, err)
+		}
+		cors.AllowCredentials = b
+	}
+	if err := durationFromEnv("CORS_MAX_AGE", &cors.MaxAge); err != nil {
+		return err
+	}
+	if err := durationFromEnv("HSTS_MAX_AGE", &security.HSTSMaxAge); err != nil {
+		return err
+	}
+
+	for _, origin := range cors.AllowedOrigins {
+		if origin == "*" && cors.AllowCredentials {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS=* can't be combined with CORS_ALLOW_CREDENTIALS")
+		}
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("invalid CORS origin %q: only one wildcard allowed", origin)
+		}
+	}
+
+	CORS = cors
+	Security = security
+	return nil
+}
+
+func splitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
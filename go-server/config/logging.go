@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// LoggingConfig controls the structured logs written to stderr
+type LoggingConfig struct {
+	Level  slog.Level
+	Format string // text | json
+}
+
+var Logging LoggingConfig
+
+// LoadLoggingConfig reads LOG_LEVEL (debug, info, warn, error) and LOG_FORMAT (text, json)
+func LoadLoggingConfig() error {
+	cfg := LoggingConfig{Level: slog.LevelInfo, Format: "text"}
+
+	if raw := os.Getenv("LOG_LEVEL"); raw != "" {
+		if err := cfg.Level.UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL %q: must be debug, info, warn or error", raw)
+		}
+	}
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid LOG_FORMAT %q: must be text or json", format)
+		}
+		cfg.Format = format
+	}
+
+	Logging = cfg
+	return nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// RateLimitConfig protects the write endpoints against floods and double submissions
+type RateLimitConfig struct {
+	Store            string // memory | postgres (shared between instances)
+	IPPerMinute      float64
+	IPBurst          int
+	TokenPerMinute   float64 // per X-Contributor-Token
+	TokenBurst       int
+	MaxBodyBytes     int
+	MaxImportBytes   int // bodies of /api/surfers/import, which take whole files
+	DuplicateWindow  time.Duration
+	TrustedProxyHops int // proxies in front of the server that append to X-Forwarded-For
+
+	// ContributorTokens are the X-Contributor-Token values that get the token limit instead of the IP limit
+	ContributorTokens []string
+}
+
+var RateLimit RateLimitConfig
+
+func LoadRateLimitConfig() error {
+	cfg := RateLimitConfig{
+		Store:           "memory",
+		IPPerMinute:     6,
+		IPBurst:         3,
+		TokenPerMinute:  30,
+		TokenBurst:      10,
+		MaxBodyBytes:    16 << 10,
+		MaxImportBytes:  5 << 20,
+		DuplicateWindow: 2 * time.Minute,
+	}
+
+	if store := os.Getenv("RATE_LIMIT_STORE"); store != "" {
+		if store != "memory" && store != "postgres" {
+			return fmt.Errorf("invalid RATE_LIMIT_STORE %q: must be memory or postgres", store)
+		}
+		cfg.Store = store
+	}
+	for key, target := range map[string]*float64{
+		"RATE_LIMIT_IP_PER_MINUTE":    &cfg.IPPerMinute,
+		"RATE_LIMIT_TOKEN_PER_MINUTE": &cfg.TokenPerMinute,
+	} {
+		if err := floatFromEnv(key, target); err != nil {
+			return err
+		}
+		if *target <= 0 {
+			return fmt.Errorf("%s must be positive", key)
+		}
+	}
+	for key, target := range map[string]*int{
+		"RATE_LIMIT_IP_BURST":    &cfg.IPBurst,
+		"RATE_LIMIT_TOKEN_BURST": &cfg.TokenBurst,
+		"MAX_BODY_BYTES":         &cfg.MaxBodyBytes,
+		"MAX_IMPORT_BYTES":       &cfg.MaxImportBytes,
+		"TRUSTED_PROXY_HOPS":     &cfg.TrustedProxyHops,
+	} {
+		if err := intFromEnv(key, target); err != nil {
+			return err
+		}
+	}
+	if cfg.IPBurst < 1 || cfg.TokenBurst < 1 || cfg.MaxBodyBytes < 1 || cfg.MaxImportBytes < 1 {
+		return fmt.Errorf("rate limit bursts, MAX_BODY_BYTES and MAX_IMPORT_BYTES must be at least 1")
+	}
+	if err := durationFromEnv("DUPLICATE_WINDOW", &cfg.DuplicateWindow); err != nil {
+		return err
+	}
+	cfg.ContributorTokens = splitList(os.Getenv("CONTRIBUTOR_TOKENS"))
+
+	RateLimit = cfg
+	return nil
+}
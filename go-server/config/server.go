@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+type ServerConfig struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+
+	// Per-endpoint budgets: handlers give up on upstream fetches after these
+	ConditionsTimeout        time.Duration
+	WaterTemperatureTimeout  time.Duration
+	PredictTimeout           time.Duration
+	PredictConditionsTimeout time.Duration
+	EntriesTimeout           time.Duration
+	ExportTimeout            time.Duration
+	ImportTimeout            time.Duration
+	ReadyTimeout             time.Duration
+
+	// Bearer token for /api/admin endpoints; admin endpoints are disabled without it
+	AdminToken string
+	// Bearer token for /metrics; without it /metrics is open
+	MetricsToken string
+}
+
+var Server ServerConfig
+
+// LoadServerConfig reads the HTTP server settings from the environment.
+// Durations use Go syntax (e.g. "15s", "1m"); unset values fall back to defaults.
+func LoadServerConfig() error {
+	cfg := ServerConfig{
+		Addr:              ":8080",
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      60 * time.Second, // GKD download can take a while
+		IdleTimeout:       120 * time.Second,
+		ShutdownTimeout:   20 * time.Second,
+
+		ConditionsTimeout:        10 * time.Second,
+		WaterTemperatureTimeout:  50 * time.Second,
+		PredictTimeout:           8 * time.Second,
+		PredictConditionsTimeout: 3 * time.Second,
+		EntriesTimeout:           10 * time.Second,
+		ExportTimeout:            60 * time.Second,
+		ImportTimeout:            60 * time.Second,
+		ReadyTimeout:             3 * time.Second,
+	}
+
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Addr = ":" + port
+	}
+	cfg.AdminToken = os.Getenv("ADMIN_TOKEN")
+	cfg.MetricsToken = os.Getenv("METRICS_TOKEN")
+
+	durations := map[string]*time.Duration{
+		"HTTP_READ_TIMEOUT":          &cfg.ReadTimeout,
+		"HTTP_READ_HEADER_TIMEOUT":   &cfg.ReadHeaderTimeout,
+		"HTTP_WRITE_TIMEOUT":         &cfg.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":          &cfg.IdleTimeout,
+		"SHUTDOWN_TIMEOUT":           &cfg.ShutdownTimeout,
+		"CONDITIONS_TIMEOUT":         &cfg.ConditionsTimeout,
+		"WATER_TEMPERATURE_TIMEOUT":  &cfg.WaterTemperatureTimeout,
+		"PREDICT_TIMEOUT":            &cfg.PredictTimeout,
+		"PREDICT_CONDITIONS_TIMEOUT": &cfg.PredictConditionsTimeout,
+		"ENTRIES_TIMEOUT":            &cfg.EntriesTimeout,
+		"EXPORT_TIMEOUT":             &cfg.ExportTimeout,
+		"IMPORT_TIMEOUT":             &cfg.ImportTimeout,
+		"READY_TIMEOUT":              &cfg.ReadyTimeout,
+	}
+	for key, target := range durations {
+		if err := durationFromEnv(key, target); err != nil {
+			return err
+		}
+	}
+
+	Server = cfg
+	return nil
+}
+
+func durationFromEnv(key string, target *time.Duration) error {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	*target = d
+	return nil
+}
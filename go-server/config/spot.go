@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+	_ "time/tzdata" // SPOT_TIMEZONE must resolve even without the container's zoneinfo
+)
+
+// SpotConfig describes where the wave is; used for weather and daylight lookups
+type SpotConfig struct {
+	Latitude  float64
+	Longitude float64
+	// Location buckets entries into local hours and weekdays
+	Location *time.Location
+}
+
+var Spot SpotConfig
+
+// LoadSpotConfig reads the spot location from SPOT_LATITUDE / SPOT_LONGITUDE and its
+// time zone from SPOT_TIMEZONE, defaulting to central Munich.
+func LoadSpotConfig() error {
+	cfg := SpotConfig{
+		Latitude:  48.137154,
+		Longitude: 11.576124,
+	}
+
+	name := os.Getenv("SPOT_TIMEZONE")
+	if name == "" {
+		name = "Europe/Berlin"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid SPOT_TIMEZONE %q: %w", name, err)
+	}
+	cfg.Location = loc
+
+	if err := floatFromEnv("SPOT_LATITUDE", &cfg.Latitude); err != nil {
+		return err
+	}
+	if err := floatFromEnv("SPOT_LONGITUDE", &cfg.Longitude); err != nil {
+		return err
+	}
+
+	Spot = cfg
+	return nil
+}
+
+func floatFromEnv(key string, target *float64) error {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	*target = f
+	return nil
+}
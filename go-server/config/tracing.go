@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// TracingConfig selects where OpenTelemetry spans go. The OTLP exporter itself reads
+// the standard OTEL_EXPORTER_OTLP_* variables (endpoint, headers, ...).
+type TracingConfig struct {
+	Exporter    string // none | otlp | console (stdout, for tests and debugging)
+	ServiceName string
+	SampleRatio float64 // share of new traces recorded; requests with a sampled parent are always recorded
+}
+
+var Tracing TracingConfig
+
+// LoadTracingConfig reads OTEL_TRACES_EXPORTER, OTEL_SERVICE_NAME and OTEL_TRACES_SAMPLER_ARG
+func LoadTracingConfig() error {
+	cfg := TracingConfig{Exporter: "none", ServiceName: "eisbachtracker", SampleRatio: 1}
+
+	if exporter := os.Getenv("OTEL_TRACES_EXPORTER"); exporter != "" {
+		if exporter != "none" && exporter != "otlp" && exporter != "console" {
+			return fmt.Errorf("invalid OTEL_TRACES_EXPORTER %q: must be none, otlp or console", exporter)
+		}
+		cfg.Exporter = exporter
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		cfg.ServiceName = name
+	}
+	if raw := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); raw != "" {
+		ratio, err := strconv.ParseFloat(raw, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %q: must be between 0 and 1", raw)
+		}
+		cfg.SampleRatio = ratio
+	}
+
+	Tracing = cfg
+	return nil
+}
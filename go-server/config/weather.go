@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type WeatherConfig struct {
+	OpenMeteoURL        string
+	OpenMeteoArchiveURL string // past weather, used to backfill entries
+	ForecastDays        int
+
+	// Default units of /api/conditions/weather/forecast; predictions always use metric
+	TemperatureUnit   string // celsius | fahrenheit
+	WindSpeedUnit     string // kmh | ms | mph | kn
+	PrecipitationUnit string // mm | inch
+}
+
+var Weather WeatherConfig
+
+// LoadWeatherConfig reads the Open-Meteo settings from the environment
+func LoadWeatherConfig() error {
+	cfg := WeatherConfig{
+		OpenMeteoURL:        "https://api.open-meteo.com/v1/forecast",
+		OpenMeteoArchiveURL: "https://archive-api.open-meteo.com/v1/archive",
+		ForecastDays:        3,
+		TemperatureUnit:     "celsius",
+		WindSpeedUnit:       "kmh",
+		PrecipitationUnit:   "mm",
+	}
+
+	if url := os.Getenv("OPEN_METEO_URL"); url != "" {
+		cfg.OpenMeteoURL = url
+	}
+	if url := os.Getenv("OPEN_METEO_ARCHIVE_URL"); url != "" {
+		cfg.OpenMeteoArchiveURL = url
+	}
+	if raw := os.Getenv("WEATHER_FORECAST_DAYS"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days < 1 || days > 16 {
+			return fmt.Errorf("invalid WEATHER_FORECAST_DAYS %q: must be 1-16", raw)
+		}
+		cfg.ForecastDays = days
+	}
+	if unit := os.Getenv("WEATHER_TEMPERATURE_UNIT"); unit != "" {
+		cfg.TemperatureUnit = unit
+	}
+	if unit := os.Getenv("WEATHER_WIND_SPEED_UNIT"); unit != "" {
+		cfg.WindSpeedUnit = unit
+	}
+	if unit := os.Getenv("WEATHER_PRECIPITATION_UNIT"); unit != "" {
+		cfg.PrecipitationUnit = unit
+	}
+
+	Weather = cfg
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv" // for loading local .env
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/tracing"
 )
 
 var Conn *pgxpool.Pool
@@ -16,8 +17,13 @@ func Init() error {
 		_ = godotenv.Load()
 	}
 
-	url := os.Getenv("DATABASE_URL")
-	pool, err := pgxpool.New(context.Background(), url)
+	cfg, err := pgxpool.ParseConfig(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return err
+	}
+	cfg.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
 	if err != nil {
 		return err
 	}
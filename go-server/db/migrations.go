@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"regexp"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+var migrationVersion = regexp.MustCompile(`^V(\d+)__.+\.sql$`)
+
+// LatestMigration is the highest Flyway version shipped with this binary
+func LatestMigration() int {
+	entries, _ := migrations.ReadDir("migrations")
+	latest := 0
+	for _, entry := range entries {
+		if m := migrationVersion.FindStringSubmatch(entry.Name()); m != nil {
+			if v, _ := strconv.Atoi(m[1]); v > latest {
+				latest = v
+			}
+		}
+	}
+	return latest
+}
+
+// MigrationStatus is what Flyway recorded in flyway_schema_history
+type MigrationStatus struct {
+	Applied  int  `json:"applied"`  // highest successfully applied version
+	Expected int  `json:"expected"` // LatestMigration
+	Failed   bool `json:"failed"`   // a migration failed and needs repair
+}
+
+// GetMigrationStatus compares the applied migrations to the ones this binary expects
+func GetMigrationStatus(ctx context.Context, pool *pgxpool.Pool) (MigrationStatus, error) {
+	status := MigrationStatus{Expected: LatestMigration()}
+	err := pool.QueryRow(ctx,
+		`SELECT COALESCE(MAX(version::int) FILTER (WHERE success), 0), COALESCE(BOOL_OR(NOT success), false)
+		FROM flyway_schema_history
+		WHERE version IS NOT NULL`).Scan(&status.Applied, &status.Failed)
+	return status, err
+}
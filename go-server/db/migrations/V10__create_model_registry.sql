@@ -0,0 +1,37 @@
+-- Versioned prediction models: one active, any number running in shadow mode
+CREATE TABLE IF NOT EXISTS prediction_models (
+  id SERIAL PRIMARY KEY,
+  version TEXT NOT NULL UNIQUE,
+  endpoint_url TEXT NOT NULL,
+  training_from TIMESTAMP,
+  training_to TIMESTAMP,
+  metrics JSONB NOT NULL DEFAULT '{}',
+  feature_schema JSONB NOT NULL DEFAULT '[]',
+  status TEXT NOT NULL DEFAULT 'candidate' CHECK (status IN ('candidate', 'active', 'shadow', 'retired')),
+  created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS prediction_models_one_active ON prediction_models (status) WHERE status = 'active';
+
+-- History of promotions, used for rollbacks
+CREATE TABLE IF NOT EXISTS model_activations (
+  id SERIAL PRIMARY KEY,
+  model_id INTEGER NOT NULL REFERENCES prediction_models(id),
+  action TEXT NOT NULL CHECK (action IN ('promote', 'rollback')),
+  activated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Outputs of shadow models, next to what the active model returned for the same request
+CREATE TABLE IF NOT EXISTS shadow_predictions (
+  id BIGSERIAL PRIMARY KEY,
+  model_id INTEGER NOT NULL REFERENCES prediction_models(id),
+  active_model_id INTEGER REFERENCES prediction_models(id),
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  features JSONB NOT NULL,
+  prediction INTEGER,
+  active_prediction INTEGER,
+  error TEXT,
+  latency_ms INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS shadow_predictions_model_created ON shadow_predictions (model_id, created_at);
@@ -0,0 +1,10 @@
+-- Anomalous reports are kept but held back from aggregates and training until reviewed
+ALTER TABLE surfer_entries
+ADD COLUMN review_status TEXT NOT NULL DEFAULT 'accepted'
+  CHECK (review_status IN ('accepted', 'flagged', 'quarantined', 'rejected')),
+ADD COLUMN anomaly_score REAL,
+ADD COLUMN anomaly_reason TEXT,
+ADD COLUMN reviewed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS surfer_entries_review_queue ON surfer_entries (review_status)
+  WHERE review_status IN ('flagged', 'quarantined');
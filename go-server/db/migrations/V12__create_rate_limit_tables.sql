@@ -0,0 +1,12 @@
+-- Shared state of the rate limiter when RATE_LIMIT_STORE=postgres (multi-instance deploys)
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+  key TEXT PRIMARY KEY,
+  tokens DOUBLE PRECISION NOT NULL,
+  allowed BOOLEAN NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_seen (
+  key TEXT PRIMARY KEY,
+  expires_at TIMESTAMPTZ NOT NULL
+);
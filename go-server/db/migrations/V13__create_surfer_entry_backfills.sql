@@ -0,0 +1,16 @@
+-- Provenance of conditions backfilled into entries that were stored with placeholders
+-- (0 or -1 from failed fetches, 18 °C from V5); one row per backfilled field
+CREATE TABLE IF NOT EXISTS surfer_entry_backfills (
+  id BIGSERIAL PRIMARY KEY,
+  entry_id INTEGER NOT NULL REFERENCES surfer_entries (id) ON DELETE CASCADE,
+  field TEXT NOT NULL
+    CHECK (field IN ('water_temperature', 'air_temperature', 'weather_condition', 'water_level', 'water_flow')),
+  old_value DOUBLE PRECISION,
+  new_value DOUBLE PRECISION NOT NULL,
+  source TEXT NOT NULL,
+  station_id TEXT,
+  observed_at TIMESTAMPTZ NOT NULL,
+  backfilled_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS surfer_entry_backfills_entry ON surfer_entry_backfills (entry_id);
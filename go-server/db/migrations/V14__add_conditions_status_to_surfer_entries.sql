@@ -0,0 +1,20 @@
+-- Conditions are snapshotted after an entry is stored. conditions_status says for each
+-- condition column whether it was measured, interpolated or is missing (and holds 0 / -1 / NULL);
+-- conditions_enriched_at stays NULL while some conditions are still being looked up.
+ALTER TABLE surfer_entries
+ADD COLUMN conditions_status JSONB,
+ADD COLUMN conditions_enriched_at TIMESTAMPTZ;
+
+-- Existing entries were stored with placeholders where a fetch failed
+UPDATE surfer_entries SET
+  conditions_enriched_at = now(),
+  conditions_status = jsonb_build_object(
+    'water_temperature', CASE WHEN water_temperature = 0 THEN 'missing' ELSE 'measured' END,
+    'air_temperature', CASE WHEN weather_condition = -1 THEN 'missing' ELSE 'measured' END,
+    'weather_condition', CASE WHEN weather_condition = -1 THEN 'missing' ELSE 'measured' END,
+    'water_level', CASE WHEN water_level IS NULL OR water_level = 0 THEN 'missing' ELSE 'measured' END,
+    'water_flow', CASE WHEN water_flow IS NULL OR water_flow = 0 THEN 'missing' ELSE 'measured' END
+  );
+
+CREATE INDEX IF NOT EXISTS surfer_entries_pending_conditions ON surfer_entries (timestamp)
+  WHERE conditions_enriched_at IS NULL;
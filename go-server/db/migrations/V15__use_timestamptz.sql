@@ -0,0 +1,18 @@
+-- Store instants instead of wall clock times. Until now every TIMESTAMP column held the UTC
+-- wall clock: now(), the server's time.Now() in its UTC container, the PWA's report timestamps
+-- (toISOString()) and bulk imports, which convert to UTC before storing. Bucketing into the
+-- spot's local hours happens in the queries (AT TIME ZONE), so it follows SPOT_TIMEZONE and DST.
+ALTER TABLE surfer_entries
+ALTER COLUMN timestamp TYPE TIMESTAMPTZ USING timestamp AT TIME ZONE 'UTC',
+ALTER COLUMN reviewed_at TYPE TIMESTAMPTZ USING reviewed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE prediction_models
+ALTER COLUMN training_from TYPE TIMESTAMPTZ USING training_from AT TIME ZONE 'UTC',
+ALTER COLUMN training_to TYPE TIMESTAMPTZ USING training_to AT TIME ZONE 'UTC',
+ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE model_activations
+ALTER COLUMN activated_at TYPE TIMESTAMPTZ USING activated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE shadow_predictions
+ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestLatestMigrationMatchesMigrationsDir(t *testing.T) {
+	entries, err := os.ReadDir("migrations")
+	if err != nil {
+		t.Fatalf("Failed to read migrations: %v", err)
+	}
+	pattern := regexp.MustCompile(`^V(\d+)__`)
+	want := 0
+	for _, entry := range entries {
+		if m := pattern.FindStringSubmatch(entry.Name()); m != nil {
+			if v, _ := strconv.Atoi(m[1]); v > want {
+				want = v
+			}
+		}
+	}
+
+	got := LatestMigration()
+	t.Logf("Latest migration: V%d", got)
+	if got != want || got == 0 {
+		t.Errorf("LatestMigration() = %d, want %d", got, want)
+	}
+}
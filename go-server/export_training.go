@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/db"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
+)
+
+// runExportTraining is the CLI counterpart of /api/export/training:
+//
+//	go run . export-training -format parquet -from 2025-05-01 -out training.parquet
+func runExportTraining(args []string) error {
+	flags := flag.NewFlagSet("export-training", flag.ContinueOnError)
+	format := flags.String("format", "csv", "csv, jsonl or parquet")
+	from := flags.String("from", "", "first day (YYYY-MM-DD) or time (RFC 3339) to export")
+	to := flags.String("to", "", "last day (YYYY-MM-DD, inclusive) or time (RFC 3339, exclusive) to export")
+	split := flags.String("split", "", "only export the train or test set")
+	testRatio := flags.Float64("test-ratio", surferdata.DefaultTestRatio, "share of entries in the test set")
+	seed := flags.String("seed", surferdata.DefaultSplitSeed, "seed of the train/test split")
+	out := flags.String("out", "", "output file (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	exportFormat, err := surferdata.ParseExportFormat(*format)
+	if err != nil {
+		return err
+	}
+	filter := surferdata.TrainingFilter{Split: *split, TestRatio: *testRatio, Seed: *seed}
+	if filter.From, err = surferdata.ParseTimeBound(*from, false); err != nil {
+		return err
+	}
+	if filter.To, err = surferdata.ParseTimeBound(*to, true); err != nil {
+		return err
+	}
+
+	if err := db.Init(); err != nil {
+		return err
+	}
+	defer db.Conn.Close()
+
+	rows, err := surferdata.NewService(db.Conn, nil, nil).GetTrainingData(context.Background(), filter)
+	if err != nil {
+		return fmt.Errorf("failed to load training data: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := surferdata.WriteTrainingData(w, exportFormat, rows); err != nil {
+		return err
+	}
+
+	slog.Info("exported training data", "rows", len(rows), "format", exportFormat)
+	return nil
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded" // works, but not as well as it should
+	StatusDown     Status = "down"
+	StatusUnknown  Status = "unknown" // not checked yet, e.g. an upstream nobody asked for since start
+)
+
+// Result is the state of one dependency
+type Result struct {
+	Status      Status     `json:"status"`
+	Critical    bool       `json:"critical"`
+	Message     string     `json:"message,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	AgeSeconds  int        `json:"age_seconds,omitempty"`
+	Details     any        `json:"details,omitempty"`
+}
+
+// Check probes one dependency. The server isn't ready while a critical check is down;
+// failing non-critical checks only degrade it.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) Result
+}
+
+// Report is the readiness of the server and the results per dependency
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready reports whether the server should receive traffic
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// Run runs all checks concurrently, bounded by ctx
+func Run(ctx context.Context, checks []Check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := check.Run(ctx)
+			result.Critical = check.Critical
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			report.Status = worse(report.Status, result)
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// worse folds a result into the overall status. Non-critical failures degrade, never take down.
+func worse(overall Status, result Result) Status {
+	switch {
+	case overall == StatusDown:
+		return StatusDown
+	case result.Status == StatusDown && result.Critical:
+		return StatusDown
+	case result.Status == StatusDown || result.Status == StatusDegraded:
+		return StatusDegraded
+	}
+	return overall
+}
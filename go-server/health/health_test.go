@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func staticCheck(name string, critical bool, status Status) Check {
+	return Check{Name: name, Critical: critical, Run: func(context.Context) Result {
+		return Result{Status: status}
+	}}
+}
+
+func TestRunDistinguishesCriticalFromDegraded(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		want   Status
+		ready  bool
+	}{
+		{"all ok", []Check{staticCheck("database", true, StatusOK), staticCheck("gkd", false, StatusUnknown)}, StatusOK, true},
+		{"upstream down", []Check{staticCheck("database", true, StatusOK), staticCheck("gkd", false, StatusDown)}, StatusDegraded, true},
+		{"database down", []Check{staticCheck("database", true, StatusDown), staticCheck("gkd", false, StatusDegraded)}, StatusDown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Run(context.Background(), tt.checks)
+			t.Logf("report: %+v", report)
+
+			if report.Status != tt.want || report.Ready() != tt.ready {
+				t.Errorf("got %s (ready %v), want %s (ready %v)", report.Status, report.Ready(), tt.want, tt.ready)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("expected a result per check, got %d", len(report.Checks))
+			}
+			if !report.Checks["database"].Critical {
+				t.Error("expected the database result to be marked critical")
+			}
+		})
+	}
+}
+
+func TestTrackerFreshness(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+
+	if got := tracker.Freshness("gkd", time.Hour); got.Status != StatusUnknown {
+		t.Errorf("expected unknown before the first fetch, got %s", got.Status)
+	}
+
+	tracker.Observe("gkd", nil)
+	now = now.Add(30 * time.Minute)
+	if got := tracker.Freshness("gkd", time.Hour); got.Status != StatusOK || got.AgeSeconds != 1800 {
+		t.Errorf("expected ok with age 1800s, got %s / %d", got.Status, got.AgeSeconds)
+	}
+
+	tracker.Observe("gkd", errors.New("timeout"))
+	if got := tracker.Freshness("gkd", time.Hour); got.Status != StatusDegraded {
+		t.Errorf("expected degraded after a failed fetch, got %s", got.Status)
+	}
+
+	tracker.Observe("gkd", nil)
+	now = now.Add(2 * time.Hour)
+	if got := tracker.Freshness("gkd", time.Hour); got.Status != StatusDegraded {
+		t.Errorf("expected degraded once the last success is too old, got %s", got.Status)
+	}
+	if got := tracker.Freshness("gkd", 0); got.Status != StatusOK {
+		t.Errorf("expected ok without an age limit, got %s", got.Status)
+	}
+}
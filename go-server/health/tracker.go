@@ -0,0 +1,72 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// Upstreams remembers the outcome of the last fetch from every upstream provider
+var Upstreams = NewTracker()
+
+// Tracker records the last success and failure per dependency
+type Tracker struct {
+	mu      sync.Mutex
+	entries map[string]*trackedUpstream
+	now     func() time.Time
+}
+
+type trackedUpstream struct {
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{entries: map[string]*trackedUpstream{}, now: time.Now}
+}
+
+// Observe records the outcome of one call to name
+func (t *Tracker) Observe(name string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[name]
+	if !ok {
+		entry = &trackedUpstream{}
+		t.entries[name] = entry
+	}
+	if err == nil {
+		entry.lastSuccess = t.now()
+	} else {
+		entry.lastFailure = t.now()
+		entry.lastError = err.Error()
+	}
+}
+
+// Freshness checks that the last call to name succeeded and that the last success
+// is younger than maxAge (zero: any age). Dependencies that weren't used yet are unknown.
+func (t *Tracker) Freshness(name string, maxAge time.Duration) Result {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[name]
+	if !ok {
+		return Result{Status: StatusUnknown, Message: "not used since start"}
+	}
+
+	result := Result{Status: StatusOK}
+	if !entry.lastSuccess.IsZero() {
+		last := entry.lastSuccess
+		result.LastSuccess = &last
+		result.AgeSeconds = int(t.now().Sub(last).Seconds())
+	}
+	switch {
+	case entry.lastFailure.After(entry.lastSuccess):
+		result.Status = StatusDegraded
+		result.Message = "last fetch failed: " + entry.lastError
+	case maxAge > 0 && t.now().Sub(entry.lastSuccess) > maxAge:
+		result.Status = StatusDegraded
+		result.Message = "last successful fetch is older than " + maxAge.String()
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/db"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
+)
+
+// runImportSurfers is the CLI counterpart of /api/surfers/import; the report is written to stdout:
+//
+//	go run . import-surfers -dry-run logbook.csv
+func runImportSurfers(args []string) error {
+	flags := flag.NewFlagSet("import-surfers", flag.ContinueOnError)
+	format := flags.String("format", "", "csv or json (default from the file extension)")
+	dryRun := flags.Bool("dry-run", false, "validate and report without storing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: import-surfers [-dry-run] [-format csv|json] FILE")
+	}
+	path := flags.Arg(0)
+
+	importFormat := surferdata.ImportFormat(*format)
+	if importFormat == "" {
+		importFormat = surferdata.ImportFormat(strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := db.Init(); err != nil {
+		return err
+	}
+	defer db.Conn.Close()
+
+	report, err := surferdata.NewService(db.Conn, nil, nil).ImportEntries(context.Background(), f, importFormat, *dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", path, err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	slog.Info("imported surfer entries", "file", path, "dry_run", *dryRun, "accepted", report.Accepted, "rejected", report.Rejected)
+	return nil
+}
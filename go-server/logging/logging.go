@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID in responses and in calls to upstream services
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID stores the request ID in ctx; every record logged with ctx carries it
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Setup makes a slog logger writing to w the default, which also routes the standard log package through it
+func Setup(w io.Writer, cfg config.LoggingConfig) {
+	slog.SetDefault(slog.New(NewHandler(w, cfg)))
+}
+
+// NewHandler returns a handler that adds request IDs and redacts secrets
+func NewHandler(w io.Writer, cfg config.LoggingConfig) slog.Handler {
+	opts := &slog.HandlerOptions{Level: cfg.Level, ReplaceAttr: redact}
+	if cfg.Format == "json" {
+		return contextHandler{slog.NewJSONHandler(w, opts)}
+	}
+	return contextHandler{slog.NewTextHandler(w, opts)}
+}
+
+// contextHandler adds the request ID and trace of the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// secretKeys are attribute names whose values are never logged
+var secretKeys = regexp.MustCompile(`(?i)(password|passwd|secret|token|authorization|cookie|database_url|dsn|api_key)`)
+
+// credentialsInURL matches the user:password@ part of connection strings and URLs
+var credentialsInURL = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s:@]+:[^/\s@]+@`)
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if secretKeys.MatchString(a.Key) {
+		return slog.String(a.Key, "[REDACTED]")
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); strings.Contains(s, "@") {
+			return slog.String(a.Key, RedactURL(s))
+		}
+	case slog.KindAny:
+		// Errors from the database driver can quote the connection string
+		if err, ok := a.Value.Any().(error); ok && strings.Contains(err.Error(), "@") {
+			return slog.String(a.Key, RedactURL(err.Error()))
+		}
+	}
+	return a
+}
+
+// RedactURL removes credentials from URLs in s
+func RedactURL(s string) string {
+	return credentialsInURL.ReplaceAllString(s, "${1}[REDACTED]@")
+}
+
+// Transport sends the request ID of the request's context upstream
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{base}
+}
+
+type roundTripper struct {
+	base http.RoundTripper
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := RequestID(req.Context()); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+	}
+	return t.base.RoundTrip(req)
+}
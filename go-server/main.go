@@ -1,55 +1,223 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/db"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/logging"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/middleware"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/openapi"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/routes"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/tracing"
 )
 
 func main() {
+	run := serve
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export-training":
+			run = func() error { return runExportTraining(os.Args[2:]) }
+		case "import-surfers":
+			run = func() error { return runImportSurfers(os.Args[2:]) }
+		case "backfill-conditions":
+			run = func() error { return runBackfillConditions(os.Args[2:]) }
+		}
+	}
+
+	if err := loadConfig(); err != nil {
+		slog.Error("startup failed", "err", err)
+		os.Exit(1)
+	}
+	if err := run(); err != nil {
+		slog.Error("exiting", "err", err)
+		os.Exit(1)
+	}
+}
+
+func loadConfig() error {
 	// Load global config
 	if err := config.LoadConfig(); err != nil {
-		log.Fatal("Failed to load config:", err)
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := config.LoadCalendarConfig(); err != nil {
+		return fmt.Errorf("failed to load calendar config: %w", err)
 	}
 
 	// Load .env if not in production
+	loadedEnv := false
 	if os.Getenv("ENV") != "production" {
-		if err := godotenv.Load(); err == nil {
-			log.Println("✅ Loaded local .env")
-		}
+		loadedEnv = godotenv.Load() == nil
+	}
+
+	// Before anything else logs, so every line has the configured level and format
+	if err := config.LoadLoggingConfig(); err != nil {
+		return fmt.Errorf("failed to load logging config: %w", err)
+	}
+	logging.Setup(os.Stderr, config.Logging)
+	if loadedEnv {
+		slog.Info("loaded local .env")
+	}
+
+	if err := config.LoadServerConfig(); err != nil {
+		return fmt.Errorf("failed to load server config: %w", err)
+	}
+	if err := config.LoadSpotConfig(); err != nil {
+		return fmt.Errorf("failed to load spot config: %w", err)
+	}
+	conditions.SetSpotLocation(config.Spot.Location)
+	if err := config.LoadWeatherConfig(); err != nil {
+		return fmt.Errorf("failed to load weather config: %w", err)
+	}
+	if err := config.LoadAnomalyConfig(); err != nil {
+		return fmt.Errorf("failed to load anomaly config: %w", err)
+	}
+	if err := config.LoadRateLimitConfig(); err != nil {
+		return fmt.Errorf("failed to load rate limit config: %w", err)
+	}
+	if err := config.LoadCORSConfig(); err != nil {
+		return fmt.Errorf("failed to load CORS config: %w", err)
+	}
+	if err := config.LoadTracingConfig(); err != nil {
+		return fmt.Errorf("failed to load tracing config: %w", err)
+	}
+	return nil
+}
+
+func serve() error {
+	serverConfig := config.Server
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
 	}
+	defer func() {
+		// Flush the spans of the last requests
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("could not flush traces", "err", err)
+		}
+	}()
 
 	// Init DB
 	if err := db.Init(); err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer db.Conn.Close()
+	prometheus.MustRegister(metrics.NewPoolCollector(db.Conn))
+
+	// Run migrations if not in production
+	if os.Getenv("ENV") != "production" {
+		if err := runMigrations(); err != nil {
+			return err
+		}
+	}
+
+	// Cancelled on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	fmt.Println("🌍 DATABASE_URL:", os.Getenv("DATABASE_URL"))
+	// Requests are validated against the OpenAPI spec before they reach a handler
+	spec, err := openapi.Spec()
+	if err != nil {
+		return fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	validateRequests, err := middleware.ValidateRequests(spec, middleware.BodyLimits{
+		Default:      int64(config.RateLimit.MaxBodyBytes),
+		PerOperation: map[string]int64{"importSurferEntries": int64(config.RateLimit.MaxImportBytes)},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
 
 	// Register Routes (with db pool)
-	routes.RegisterRoutes(db.Conn)
+	mux := http.NewServeMux()
+	workers := routes.RegisterRoutes(mux, db.Conn)
 
-	// Run migrations if not in production
-	if os.Getenv("ENV") != "production" {
-		runMigrations()
+	// Background pollers run until shutdown
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker(workerCtx)
+		}()
+	}
+
+	// The request ID comes first so the access log and everything below can use it
+	handler := middleware.Chain(mux,
+		middleware.RequestID(),
+		middleware.Tracing(),
+		middleware.AccessLog(config.RateLimit.TrustedProxyHops),
+		middleware.Metrics(),
+		middleware.SecurityHeaders(config.Security),
+		middleware.CORS(config.CORS),
+		validateRequests,
+	)
+
+	server := &http.Server{
+		Addr:              serverConfig.Addr,
+		Handler:           handler,
+		ReadTimeout:       serverConfig.ReadTimeout,
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", serverConfig.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		stopWorkers()
+		wg.Wait()
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+		slog.Info("shutting down, draining in-flight requests")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	stopWorkers()
+	wg.Wait()
+	if err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
 	}
 
-	fmt.Println("🚀 Listening on http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	slog.Info("server stopped")
+	return nil
 }
 
-func runMigrations() {
+func runMigrations() error {
 	cmd := exec.Command("flyway", "migrate")
 	if err := cmd.Run(); err != nil {
-		log.Fatalf("Failed to execute Flyway migrations: %v", err)
+		return fmt.Errorf("failed to execute Flyway migrations: %w", err)
 	}
-	log.Println("✅ Database migrations applied successfully.")
+	slog.Info("database migrations applied")
+	return nil
 }
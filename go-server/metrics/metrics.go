@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "eisbach"
+
+// ProviderMLService labels calls to the ML prediction service; the conditions
+// providers use their source names (conditions.SourceGKD, ...)
+const ProviderMLService = "ml-service"
+
+// Outcome labels of upstream calls
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and method.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"route", "method"})
+
+	UpstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Fetches from upstream providers by outcome (success, error).",
+	}, []string{"provider", "outcome"})
+
+	UpstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Upstream fetch latency by provider, including parsing.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 20, 40, 60},
+	}, []string{"provider"})
+
+	CircuitOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "upstream_circuit_open",
+		Help:      "1 while the circuit breaker of a provider is open.",
+	}, []string{"provider"})
+
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result (hit, stale, miss, error).",
+	}, []string{"cache", "result"})
+
+	SurferReports = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "surfer_reports_total",
+		Help:      "Stored surfer reports by review status.",
+	}, []string{"review_status"})
+
+	Predictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "predictions_total",
+		Help:      "Surfer count predictions by source (ml, rule_based).",
+	}, []string{"source"})
+
+	RateLimitDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ratelimit_decisions_total",
+		Help:      "Write protection decisions by limiter and outcome.",
+	}, []string{"limiter", "outcome"})
+)
+
+// ObserveUpstream records one upstream call
+func ObserveUpstream(provider string, took time.Duration, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+	UpstreamRequests.WithLabelValues(provider, outcome).Inc()
+	UpstreamDuration.WithLabelValues(provider).Observe(took.Seconds())
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector exposes pgxpool statistics, read on every scrape
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired, idle, constructing, total, max                  *prometheus.Desc
+	acquires, acquireSeconds, canceledAcquires, emptyAcquires *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &PoolCollector{
+		pool:             pool,
+		acquired:         desc("acquired_connections", "Connections currently in use."),
+		idle:             desc("idle_connections", "Idle connections in the pool."),
+		constructing:     desc("constructing_connections", "Connections being opened."),
+		total:            desc("total_connections", "All connections in the pool."),
+		max:              desc("max_connections", "Maximum size of the pool."),
+		acquires:         desc("acquires_total", "Successful connection acquires."),
+		acquireSeconds:   desc("acquire_duration_seconds_total", "Time spent acquiring connections."),
+		canceledAcquires: desc("canceled_acquires_total", "Acquires cancelled by their context."),
+		emptyAcquires:    desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.acquired, c.idle, c.constructing, c.total, c.max,
+		c.acquires, c.acquireSeconds, c.canceledAcquires, c.emptyAcquires} {
+		ch <- d
+	}
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquired, float64(stat.AcquiredConns()))
+	gauge(c.idle, float64(stat.IdleConns()))
+	gauge(c.constructing, float64(stat.ConstructingConns()))
+	gauge(c.total, float64(stat.TotalConns()))
+	gauge(c.max, float64(stat.MaxConns()))
+	counter(c.acquires, float64(stat.AcquireCount()))
+	counter(c.acquireSeconds, stat.AcquireDuration().Seconds())
+	counter(c.canceledAcquires, float64(stat.CanceledAcquireCount()))
+	counter(c.emptyAcquires, float64(stat.EmptyAcquireCount()))
+}
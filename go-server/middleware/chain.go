@@ -0,0 +1,14 @@
+package middleware
+
+import "net/http"
+
+// Middleware wraps a handler; applied once around the whole router
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps handler so that the first middleware sees the request first
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
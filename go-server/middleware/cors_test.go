@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+)
+
+var testCORS = config.CORSConfig{
+	AllowedOrigins: []string{"https://vr33ni.github.io", "capacitor://localhost", "https://*.eisbach.dev"},
+	AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+	AllowedHeaders: []string{"Content-Type"},
+	ExposedHeaders: []string{"Retry-After"},
+	MaxAge:         10 * time.Minute,
+}
+
+func serveCORS(method, origin, requestMethod string) *httptest.ResponseRecorder {
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), SecurityHeaders(config.SecurityConfig{}), CORS(testCORS))
+
+	req := httptest.NewRequest(method, "/api/surfers", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if requestMethod != "" {
+		req.Header.Set("Access-Control-Request-Method", requestMethod)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSPreflightFromCapacitor(t *testing.T) {
+	rec := serveCORS(http.MethodOptions, "capacitor://localhost", http.MethodPost)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for an allowed preflight, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "capacitor://localhost" {
+		t.Errorf("expected the Capacitor origin to be allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, OPTIONS" {
+		t.Errorf("expected POST to be allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("expected max age 600, got %q", got)
+	}
+}
+
+func TestCORSRejectsUnknownOrigins(t *testing.T) {
+	if rec := serveCORS(http.MethodOptions, "https://evil.example", http.MethodPost); rec.Code != http.StatusForbidden {
+		t.Errorf("expected preflight from unknown origin to be refused, got %d", rec.Code)
+	}
+
+	rec := serveCORS(http.MethodGet, "https://evil.example", "")
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS headers for unknown origins")
+	}
+	if rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("expected security headers on every response")
+	}
+}
+
+func TestCORSWildcardSubdomain(t *testing.T) {
+	rec := serveCORS(http.MethodGet, "https://preview-42.eisbach.dev", "")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://preview-42.eisbach.dev" {
+		t.Errorf("expected wildcard subdomain to be allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "Retry-After" {
+		t.Errorf("expected exposed headers on simple requests, got %q", got)
+	}
+
+	if rec := serveCORS(http.MethodGet, "https://eisbach.dev.evil.example", ""); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected lookalike origin to be refused")
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/logging"
+)
+
+func serveLogged(t *testing.T, req *http.Request) (*httptest.ResponseRecorder, map[string]any, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logging.NewHandler(&buf, config.LoggingConfig{Level: slog.LevelInfo, Format: "json"})))
+	defer slog.SetDefault(previous)
+
+	var seenID string
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenID = logging.RequestID(r.Context())
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("ok"))
+	}), RequestID(), AccessLog(0))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("invalid access log %q: %v", buf.String(), err)
+	}
+	t.Logf("access log: %s", buf.String())
+	return rec, line, seenID
+}
+
+func TestAccessLogWithGeneratedRequestID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/surfers?token=secret", nil)
+	rec, line, seenID := serveLogged(t, req)
+
+	id := rec.Header().Get(logging.RequestIDHeader)
+	if id == "" || id != seenID {
+		t.Fatalf("expected the generated request ID in the response and the context, got %q and %q", id, seenID)
+	}
+	if line["request_id"] != id {
+		t.Errorf("expected request_id %q in the access log, got %v", id, line["request_id"])
+	}
+	if line["method"] != "POST" || line["path"] != "/api/surfers" {
+		t.Errorf("unexpected method/path: %v %v", line["method"], line["path"])
+	}
+	if line["status"] != float64(http.StatusCreated) || line["bytes"] != float64(2) {
+		t.Errorf("unexpected status/bytes: %v %v", line["status"], line["bytes"])
+	}
+	if _, ok := line["duration_ms"]; !ok {
+		t.Error("expected duration_ms in the access log")
+	}
+}
+
+func TestRequestIDFromClient(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/conditions", nil)
+	req.Header.Set(logging.RequestIDHeader, "app-42")
+	rec, _, seenID := serveLogged(t, req)
+
+	if got := rec.Header().Get(logging.RequestIDHeader); got != "app-42" || seenID != "app-42" {
+		t.Errorf("expected the client's request ID to be kept, got %q / %q", got, seenID)
+	}
+}
+
+func TestRequestIDRejectsInjection(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/conditions", nil)
+	req.Header.Set(logging.RequestIDHeader, "x\" admin=true")
+	rec, _, _ := serveLogged(t, req)
+
+	if got := rec.Header().Get(logging.RequestIDHeader); got == "x\" admin=true" || got == "" {
+		t.Errorf("expected a generated request ID instead of the invalid one, got %q", got)
+	}
+}
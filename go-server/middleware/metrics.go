@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+)
+
+// Metrics counts requests and their latency by route. The route is the ServeMux pattern the
+// request matched (so /api/admin/reviews/{id} is one series); the mux sets it on the request
+// passed down, so no middleware between this one and the mux may replace the request.
+func Metrics() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			route := r.Pattern
+			if route == "" {
+				route = "unmatched" // preflights and 404s, kept in one series
+			}
+			method := methodLabel(r.Method)
+			metrics.HTTPRequests.WithLabelValues(route, method, strconv.Itoa(rec.status)).Inc()
+			metrics.HTTPDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// methodLabel keeps made-up methods from creating new series
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "other"
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+)
+
+func TestMetricsLabelsByRoutePattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/admin/reviews/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	handler := Chain(mux, RequestID(), Metrics())
+
+	for _, path := range []string{"/api/admin/reviews/1", "/api/admin/reviews/2", "/nope"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	if got := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/api/admin/reviews/{id}", "POST", "202")); got != 2 {
+		t.Errorf("expected 2 requests for the route pattern, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("unmatched", "POST", "404")); got != 1 {
+		t.Errorf("expected 1 unmatched request, got %v", got)
+	}
+}
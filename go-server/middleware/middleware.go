@@ -1,15 +1,41 @@
 package middleware
 
-import "net/http"
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
 
-func WithCORS(handler http.HandlerFunc) http.HandlerFunc {
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+)
+
+// WithTimeout gives the handler's request context a deadline, so every upstream
+// call made with r.Context() is bounded by the endpoint's budget. Zero means no budget.
+func WithTimeout(budget time.Duration, handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		if budget <= 0 {
+			handler(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), budget)
+		defer cancel()
+		handler(w, r.WithContext(ctx))
+	}
+}
 
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
+// WithAdminToken only lets requests with "Authorization: Bearer <token>" through.
+// Without a configured token admin endpoints are disabled.
+func WithAdminToken(token string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			api.Error(w, r, http.StatusForbidden, api.CodeAdminDisabled, "Admin endpoints are disabled")
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			api.Error(w, r, http.StatusUnauthorized, api.CodeUnauthorized, "Unauthorized")
 			return
 		}
 		handler(w, r)
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/ratelimit"
+)
+
+// ContributorTokenHeader identifies a regular contributor, who gets their own (larger) rate limit
+const ContributorTokenHeader = "X-Contributor-Token"
+
+// WriteProtection guards endpoints that store data
+type WriteProtection struct {
+	PerIP            *ratelimit.Limiter
+	PerToken         *ratelimit.Limiter
+	Duplicates       *ratelimit.Deduplicator
+	MaxBodyBytes     int64
+	TrustedProxyHops int
+	contributors     map[string]bool // hashes of the known contributor tokens
+}
+
+// AllowContributors sets the contributor tokens that get PerToken instead of PerIP
+func (p *WriteProtection) AllowContributors(tokens []string) {
+	p.contributors = make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		p.contributors[hash(token)] = true
+	}
+}
+
+// WithWriteProtection limits the body size, applies the rate limit (429 with Retry-After) and
+// rejects repeated submissions (409). Known contributor tokens are limited per token, everyone
+// else per IP; unknown tokens are ignored. Reads (GET, HEAD, OPTIONS) pass through untouched.
+func WithWriteProtection(p *WriteProtection, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			handler(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, p.MaxBodyBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			metrics.RateLimitDecisions.WithLabelValues(ratelimit.BodySizeName, ratelimit.OutcomeBodyTooLarge).Inc()
+			api.Error(w, r, http.StatusRequestEntityTooLarge, api.CodeBodyTooLarge, "Request body too large")
+			return
+		}
+		if err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, "Invalid input")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		limiter, key := p.PerIP, ClientIP(r, p.TrustedProxyHops)
+		sender := "ip:" + key
+		if token := r.Header.Get(ContributorTokenHeader); token != "" && p.contributors[hash(token)] {
+			limiter, key = p.PerToken, hash(token)
+			sender = "token:" + key
+		}
+		if allowed, retryAfter := limiter.Allow(r.Context(), key); !allowed {
+			tooManyRequests(w, r, retryAfter.Seconds())
+			return
+		}
+
+		if p.Duplicates == nil {
+			handler(w, r)
+			return
+		}
+		// Recorded up front so concurrent copies are rejected too, and forgotten again if the
+		// submission fails, so the client can retry it
+		submission := hash(sender, r.Method, r.URL.Path, string(body))
+		if p.Duplicates.Seen(r.Context(), submission) {
+			api.Error(w, r, http.StatusConflict, api.CodeDuplicateSubmission, "Duplicate submission")
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(rec, r)
+		if rec.status < 200 || rec.status >= 300 {
+			p.Duplicates.Forget(context.WithoutCancel(r.Context()), submission)
+		}
+	}
+}
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request, retryAfterSeconds float64) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(retryAfterSeconds)))))
+	api.Error(w, r, http.StatusTooManyRequests, api.CodeRateLimited, "Too many requests")
+}
+
+// ClientIP returns the caller's address. With trustedHops proxies in front of the server, the
+// client is the entry that many places from the end of X-Forwarded-For; anything before that
+// is client-supplied and can't be trusted.
+func ClientIP(r *http.Request, trustedHops int) string {
+	if trustedHops > 0 {
+		forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		if i := len(forwarded) - trustedHops; i >= 0 && r.Header.Get("X-Forwarded-For") != "" {
+			if ip := strings.TrimSpace(forwarded[i]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/ratelimit"
+)
+
+func newTestProtection() *WriteProtection {
+	store := ratelimit.NewMemoryStore()
+	p := &WriteProtection{
+		PerIP:        ratelimit.NewLimiter("ip", store, 6, 2),
+		PerToken:     ratelimit.NewLimiter("contributor_token", store, 30, 5),
+		Duplicates:   ratelimit.NewDeduplicator(store, time.Minute),
+		MaxBodyBytes: 64,
+	}
+	p.AllowContributors([]string{"regular"})
+	return p
+}
+
+func post(handler http.HandlerFunc, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/surfers", strings.NewReader(body))
+	req.RemoteAddr = "203.0.113.7:51234"
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestWriteProtection(t *testing.T) {
+	handler := WithWriteProtection(newTestProtection(), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	if rec := post(handler, `{"count": 5}`, nil); rec.Code != http.StatusCreated {
+		t.Fatalf("expected first report to be saved, got %d", rec.Code)
+	}
+	if rec := post(handler, `{"count": 5}`, nil); rec.Code != http.StatusConflict {
+		t.Errorf("expected duplicate report to be rejected, got %d", rec.Code)
+	}
+
+	rec := post(handler, `{"count": 6}`, nil)
+	t.Logf("3rd request: %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "10" {
+		t.Errorf("expected 429 with Retry-After 10 once the burst is used, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	if rec := post(handler, `{"count": 7, "padding": "`+strings.Repeat("x", 64)+`"}`, nil); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected oversized body to be rejected, got %d", rec.Code)
+	}
+
+	get := httptest.NewRecorder()
+	handler(get, httptest.NewRequest(http.MethodGet, "/api/surfers", nil))
+	if get.Code != http.StatusCreated {
+		t.Errorf("expected reads to pass through, got %d", get.Code)
+	}
+}
+
+func TestFailedSubmissionCanBeRetried(t *testing.T) {
+	// A contributor, so the IP burst doesn't run out first
+	token := map[string]string{ContributorTokenHeader: "regular"}
+	fail := true
+	handler := WithWriteProtection(newTestProtection(), func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	if rec := post(handler, `{"count": 5}`, token); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected the first report to fail, got %d", rec.Code)
+	}
+	fail = false
+	if rec := post(handler, `{"count": 5}`, token); rec.Code != http.StatusCreated {
+		t.Errorf("expected the retry of a failed report to be saved, got %d", rec.Code)
+	}
+	if rec := post(handler, `{"count": 5}`, token); rec.Code != http.StatusConflict {
+		t.Errorf("expected the saved report to be a duplicate now, got %d", rec.Code)
+	}
+}
+
+func TestWriteProtectionContributorToken(t *testing.T) {
+	handler := WithWriteProtection(newTestProtection(), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	contributor := map[string]string{ContributorTokenHeader: "regular"}
+
+	// The token burst (5) instead of the IP burst (2), from the same address
+	for i := range 5 {
+		if rec := post(handler, fmt.Sprintf(`{"count": %d}`, i), contributor); rec.Code != http.StatusCreated {
+			t.Fatalf("expected report %d of a contributor to be saved, got %d", i+1, rec.Code)
+		}
+	}
+	if rec := post(handler, `{"count": 5}`, contributor); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the token burst to be used up, got %d", rec.Code)
+	}
+
+	// The IP bucket is untouched, but an unknown token only gets the IP limit
+	unknown := map[string]string{ContributorTokenHeader: "made-up"}
+	for i := range 2 {
+		if rec := post(handler, fmt.Sprintf(`{"count": %d}`, 10+i), unknown); rec.Code != http.StatusCreated {
+			t.Fatalf("expected report %d with an unknown token to be saved, got %d", i+1, rec.Code)
+		}
+	}
+	if rec := post(handler, `{"count": 12}`, map[string]string{ContributorTokenHeader: "another"}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected a new unknown token not to get a fresh bucket, got %d", rec.Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.RemoteAddr = "10.0.0.2:4711"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 198.51.100.4")
+
+	if got := ClientIP(req, 0); got != "10.0.0.2" {
+		t.Errorf("expected remote address without trusted proxies, got %s", got)
+	}
+	if got := ClientIP(req, 1); got != "198.51.100.4" {
+		t.Errorf("expected the address added by our proxy, got %s", got)
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/logging"
+)
+
+// Tracing starts a server span per request, continuing the caller's trace if it sent one.
+// Once the mux has matched the request the span is named after the route pattern.
+// otelhttp replaces the request, so middlewares reading r.Pattern (Metrics) must come after this one.
+func Tracing() Middleware {
+	return func(next http.Handler) http.Handler {
+		named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			span := trace.SpanFromContext(r.Context())
+			if id := logging.RequestID(r.Context()); id != "" {
+				span.SetAttributes(attribute.String("request.id", id))
+			}
+			next.ServeHTTP(w, r)
+			if r.Pattern != "" {
+				span.SetName(r.Method + " " + r.Pattern)
+				span.SetAttributes(semconv.HTTPRoute(r.Pattern))
+			}
+		})
+		return otelhttp.NewHandler(named, "http.request",
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string { return r.Method }))
+	}
+}
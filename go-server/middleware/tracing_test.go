@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/tracing"
+)
+
+func TestTracingNamesSpansByRouteAndPropagatesUpstream(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer provider.Shutdown(context.Background())
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: tracing.Transport(nil)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/surfers/predict", func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, upstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("upstream call failed: %v", err)
+			return
+		}
+		resp.Body.Close()
+	})
+	handler := Chain(mux, RequestID(), Tracing())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/surfers/predict?hour=12", nil))
+
+	spans := exporter.GetSpans()
+	var server *tracetest.SpanStub
+	for i := range spans {
+		t.Logf("span %q (trace %s)", spans[i].Name, spans[i].SpanContext.TraceID())
+		if spans[i].Name == "GET /api/surfers/predict" {
+			server = &spans[i]
+		}
+	}
+	if server == nil {
+		t.Fatalf("expected a server span named after the route, got %d spans", len(spans))
+	}
+	if len(spans) != 2 {
+		t.Errorf("expected a server and a client span, got %d", len(spans))
+	}
+	if !strings.Contains(traceparent, server.SpanContext.TraceID().String()) {
+		t.Errorf("expected upstream traceparent to carry trace %s, got %q", server.SpanContext.TraceID(), traceparent)
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+)
+
+// ValidateRequests rejects requests whose parameters or body don't match the operation in doc
+// with 400. Deprecated unversioned routes are validated like their versioned successor.
+// Requests for paths or methods doc doesn't know are left to the router (404/405).
+// Authentication is left to the handlers. Bodies larger than their limit are rejected with 413.
+func ValidateRequests(doc *openapi3.T, limits BodyLimits) (Middleware, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	options := &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc}
+	options.WithCustomSchemaErrorFunc(schemaErrorMessage)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lookup := r
+			if api.IsLegacy(r) {
+				lookup = r.Clone(r.Context())
+				lookup.URL.Path = api.Successor(r.URL.Path)
+			}
+			route, pathParams, err := router.FindRoute(lookup)
+			var routeErr *routers.RouteError
+			if errors.As(err, &routeErr) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "")
+				return
+			}
+
+			// The validator reads the body; buffer it (bounded) so the handler can read it again
+			if route.Operation.RequestBody != nil && r.Body != nil {
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limits.of(route.Operation)))
+				if err != nil {
+					api.Error(w, r, http.StatusRequestEntityTooLarge, api.CodeBodyTooLarge, "Request body too large")
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			})
+			if err != nil {
+				code := api.CodeInvalidParameter
+				var requestErr *openapi3filter.RequestError
+				if errors.As(err, &requestErr) && requestErr.RequestBody != nil {
+					code = api.CodeInvalidBody
+				}
+				api.Error(w, r, http.StatusBadRequest, code, "Invalid request: "+err.Error())
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// BodyLimits bounds request bodies in bytes: Default, unless PerOperation (by operationId) has a limit
+type BodyLimits struct {
+	Default      int64
+	PerOperation map[string]int64
+}
+
+func (l BodyLimits) of(operation *openapi3.Operation) int64 {
+	if limit, ok := l.PerOperation[operation.OperationID]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// schemaErrorMessage leaves the schema and value out of validation errors, they are echoed to clients
+func schemaErrorMessage(err *openapi3.SchemaError) string {
+	if pointer := err.JSONPointer(); len(pointer) > 0 {
+		return err.Reason + " at /" + strings.Join(pointer, "/")
+	}
+	return err.Reason
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/openapi"
+)
+
+func TestValidateRequests(t *testing.T) {
+	spec, err := openapi.Spec()
+	if err != nil {
+		t.Fatalf("OpenAPI spec is invalid: %v", err)
+	}
+	validate, err := ValidateRequests(spec, BodyLimits{Default: 64, PerOperation: map[string]int64{"importSurferEntries": 256}})
+	if err != nil {
+		t.Fatalf("ValidateRequests failed: %v", err)
+	}
+
+	var body string
+	handler := validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+
+	cases := []struct {
+		method, target, body string
+		status               int
+	}{
+		{http.MethodGet, "/api/surfers/predict?hour=14", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/surfers/predict?hour=14", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/surfers/predict?hour=25", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/surfers/predict?hour=25", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/surfers/predict?hour=noon", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/conditions/weather/forecast?temperature_unit=kelvin", "", http.StatusBadRequest},
+		{http.MethodPost, "/api/surfers", `{"count": 4}`, http.StatusOK},
+		{http.MethodPost, "/api/surfers", `{"count": -4}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/surfers", `{"timestamp": "2025-06-21T12:00:00Z"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/surfers", `{"count": 4, "note": "` + strings.Repeat("x", 64) + `"}`, http.StatusRequestEntityTooLarge},
+		{http.MethodPost, "/api/admin/reviews/abc", `{"decision": "accept"}`, http.StatusBadRequest},
+		// Imports have their own body limit
+		{http.MethodPost, "/api/v1/surfers/import", `[` + strings.Repeat(`{"timestamp": "2025-06-21 12:00", "count": 4},`, 2) + `{}]`, http.StatusOK},
+		{http.MethodPost, "/api/v1/surfers/import", `[` + strings.Repeat(`{"timestamp": "2025-06-21 12:00", "count": 4},`, 6) + `{}]`, http.StatusRequestEntityTooLarge},
+		{http.MethodPost, "/api/v1/surfers/import", `{"count": 4}`, http.StatusBadRequest},
+		// Unknown paths and methods are left to the router
+		{http.MethodGet, "/api/unknown", "", http.StatusOK},
+		{http.MethodDelete, "/api/surfers", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		body = ""
+		req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+		if tc.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		t.Logf("%s %s → %d %s", tc.method, tc.target, rec.Code, strings.TrimSpace(rec.Body.String()))
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: expected %d, got %d", tc.method, tc.target, tc.status, rec.Code)
+		}
+		if rec.Code == http.StatusOK && body != tc.body {
+			t.Errorf("%s %s: handler read body %q, expected %q", tc.method, tc.target, body, tc.body)
+		}
+	}
+}
+
+func TestValidateRequestsErrorFormat(t *testing.T) {
+	spec, err := openapi.Spec()
+	if err != nil {
+		t.Fatalf("OpenAPI spec is invalid: %v", err)
+	}
+	validate, err := ValidateRequests(spec, BodyLimits{Default: 1024})
+	if err != nil {
+		t.Fatalf("ValidateRequests failed: %v", err)
+	}
+	handler := validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for target, contentType := range map[string]string{
+		"/api/v1/surfers/predict?hour=25": "application/problem+json",
+		"/api/surfers/predict?hour=25":    "text/plain; charset=utf-8",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		t.Logf("%s → %d %s", target, rec.Code, strings.TrimSpace(rec.Body.String()))
+		if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != contentType {
+			t.Errorf("%s: expected 400 %s, got %d %s", target, contentType, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+}
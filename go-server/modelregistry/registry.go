@@ -0,0 +1,245 @@
+package modelregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	StatusCandidate = "candidate"
+	StatusActive    = "active"
+	StatusShadow    = "shadow"
+	StatusRetired   = "retired"
+)
+
+var (
+	ErrNotFound      = errors.New("model not found")
+	ErrNoRollback    = errors.New("no previously active model to roll back to")
+	ErrInvalidStatus = errors.New("invalid model status")
+	ErrVersionExists = errors.New("model version already registered")
+)
+
+// Model is one trained version of the surfer prediction model, served by the ML service at EndpointURL
+type Model struct {
+	ID            int                `json:"id"`
+	Version       string             `json:"version"`
+	EndpointURL   string             `json:"endpoint_url"`
+	TrainingFrom  *time.Time         `json:"training_from,omitempty"`
+	TrainingTo    *time.Time         `json:"training_to,omitempty"`
+	Metrics       map[string]float64 `json:"metrics"`
+	FeatureSchema []string           `json:"feature_schema"` // features the model expects; empty sends all
+	Status        string             `json:"status"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+// Features reduces the full feature set to the model's schema
+func (m Model) Features(all map[string]any) map[string]any {
+	if len(m.FeatureSchema) == 0 {
+		return all
+	}
+	selected := make(map[string]any, len(m.FeatureSchema))
+	for _, name := range m.FeatureSchema {
+		if v, ok := all[name]; ok {
+			selected[name] = v
+		}
+	}
+	return selected
+}
+
+type Registry struct {
+	DB *pgxpool.Pool
+}
+
+func New(db *pgxpool.Pool) *Registry {
+	return &Registry{DB: db}
+}
+
+const modelColumns = `id, version, endpoint_url, training_from, training_to, metrics, feature_schema, status, created_at`
+
+func scanModel(row pgx.Row) (Model, error) {
+	var m Model
+	err := row.Scan(&m.ID, &m.Version, &m.EndpointURL, &m.TrainingFrom, &m.TrainingTo, &m.Metrics, &m.FeatureSchema, &m.Status, &m.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return m, ErrNotFound
+	}
+	return m, err
+}
+
+func (r *Registry) List(ctx context.Context) ([]Model, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+modelColumns+` FROM prediction_models ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var models []Model
+	for rows.Next() {
+		m, err := scanModel(rows)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, m)
+	}
+	return models, rows.Err()
+}
+
+func (r *Registry) Get(ctx context.Context, version string) (Model, error) {
+	return scanModel(r.DB.QueryRow(ctx, `SELECT `+modelColumns+` FROM prediction_models WHERE version = $1`, version))
+}
+
+// Register adds a new model as candidate; it serves nothing until promoted or put in shadow mode
+func (r *Registry) Register(ctx context.Context, m Model) (Model, error) {
+	if m.Version == "" || m.EndpointURL == "" {
+		return Model{}, fmt.Errorf("version and endpoint_url are required")
+	}
+	if m.Metrics == nil {
+		m.Metrics = map[string]float64{}
+	}
+	if m.FeatureSchema == nil {
+		m.FeatureSchema = []string{}
+	}
+	created, err := scanModel(r.DB.QueryRow(ctx,
+		`INSERT INTO prediction_models (version, endpoint_url, training_from, training_to, metrics, feature_schema)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING `+modelColumns,
+		m.Version, m.EndpointURL, m.TrainingFrom, m.TrainingTo, m.Metrics, m.FeatureSchema))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return Model{}, ErrVersionExists
+	}
+	return created, err
+}
+
+// Serving returns the active model (nil if none was promoted yet) and the models in shadow mode
+func (r *Registry) Serving(ctx context.Context) (*Model, []Model, error) {
+	rows, err := r.DB.Query(ctx,
+		`SELECT `+modelColumns+` FROM prediction_models WHERE status IN ('active', 'shadow') ORDER BY version`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var active *Model
+	var shadows []Model
+	for rows.Next() {
+		m, err := scanModel(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		if m.Status == StatusActive {
+			active = &m
+		} else {
+			shadows = append(shadows, m)
+		}
+	}
+	return active, shadows, rows.Err()
+}
+
+// SetStatus moves a model between candidate, shadow and retired. Use Promote to activate one.
+func (r *Registry) SetStatus(ctx context.Context, version, status string) (Model, error) {
+	if status != StatusCandidate && status != StatusShadow && status != StatusRetired {
+		return Model{}, ErrInvalidStatus
+	}
+	return scanModel(r.DB.QueryRow(ctx,
+		`UPDATE prediction_models SET status = $2 WHERE version = $1 AND status <> 'active' RETURNING `+modelColumns,
+		version, status))
+}
+
+// Promote makes a model the active one. The previously active model goes back to candidate.
+func (r *Registry) Promote(ctx context.Context, version string) (Model, error) {
+	return r.activate(ctx, version, "promote")
+}
+
+// Rollback re-activates the model that was active before the current one. Rolling back
+// again goes further back instead of returning to the model rolled back from.
+func (r *Registry) Rollback(ctx context.Context) (Model, error) {
+	rows, err := r.DB.Query(ctx,
+		`SELECT m.version, a.action FROM model_activations a
+		 JOIN prediction_models m ON m.id = a.model_id
+		 ORDER BY a.activated_at, a.id`)
+	if err != nil {
+		return Model{}, err
+	}
+	var history []activation
+	for rows.Next() {
+		var a activation
+		if err := rows.Scan(&a.version, &a.action); err != nil {
+			rows.Close()
+			return Model{}, err
+		}
+		history = append(history, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Model{}, err
+	}
+
+	version, ok := rollbackTarget(history)
+	if !ok {
+		return Model{}, ErrNoRollback
+	}
+	return r.activate(ctx, version, "rollback")
+}
+
+// activation is one entry of model_activations
+type activation struct {
+	version string
+	action  string
+}
+
+// rollbackTarget replays the activation history as a stack: a promotion pushes the model,
+// a rollback pops back to the model it re-activated. The target is the model below the top.
+func rollbackTarget(history []activation) (string, bool) {
+	var stack []string
+	for _, a := range history {
+		if a.action == "rollback" {
+			for len(stack) > 0 && stack[len(stack)-1] != a.version {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) > 0 {
+				continue
+			}
+		}
+		stack = append(stack, a.version)
+	}
+	if len(stack) == 0 {
+		return "", false
+	}
+	// Promoting the active model again doesn't make it its own predecessor
+	current := stack[len(stack)-1]
+	for len(stack) > 0 && stack[len(stack)-1] == current {
+		stack = stack[:len(stack)-1]
+	}
+	if len(stack) == 0 {
+		return "", false
+	}
+	return stack[len(stack)-1], true
+}
+
+func (r *Registry) activate(ctx context.Context, version, action string) (Model, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return Model{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE prediction_models SET status = 'candidate' WHERE status = 'active' AND version <> $1`, version); err != nil {
+		return Model{}, err
+	}
+	m, err := scanModel(tx.QueryRow(ctx,
+		`UPDATE prediction_models SET status = 'active' WHERE version = $1 RETURNING `+modelColumns, version))
+	if err != nil {
+		return Model{}, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO model_activations (model_id, action) VALUES ($1, $2)`, m.ID, action); err != nil {
+		return Model{}, err
+	}
+
+	return m, tx.Commit(ctx)
+}
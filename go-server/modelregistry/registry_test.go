@@ -0,0 +1,127 @@
+package modelregistry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/testutils"
+)
+
+func TestModelFeaturesFollowSchema(t *testing.T) {
+	all := map[string]any{"hour": 7, "water_temp": 15.5, "weekday": 5, "is_weekend": 1}
+
+	legacy := Model{Version: "v1"}
+	if got := legacy.Features(all); len(got) != len(all) {
+		t.Errorf("expected all features without a schema, got %v", got)
+	}
+
+	model := Model{Version: "v2", FeatureSchema: []string{"hour", "weekday", "not_computed"}}
+	got := model.Features(all)
+	t.Logf("features sent to v2: %v", got)
+	if len(got) != 2 || got["hour"] != 7 || got["weekday"] != 5 {
+		t.Errorf("expected only hour and weekday, got %v", got)
+	}
+}
+
+func TestRollbackTargetWalksBack(t *testing.T) {
+	promote := func(v string) activation { return activation{v, "promote"} }
+	rollback := func(v string) activation { return activation{v, "rollback"} }
+
+	cases := []struct {
+		name    string
+		history []activation
+		want    string
+	}{
+		{"nothing promoted", nil, ""},
+		{"only one model", []activation{promote("a")}, ""},
+		{"previous model", []activation{promote("a"), promote("b"), promote("c")}, "b"},
+		{"second rollback goes further back", []activation{promote("a"), promote("b"), promote("c"), rollback("b")}, "a"},
+		{"no further back", []activation{promote("a"), promote("b"), rollback("a")}, ""},
+		{"promoted again", []activation{promote("a"), promote("b"), promote("b")}, "a"},
+		{"promotion after rollback", []activation{promote("a"), promote("b"), rollback("a"), promote("c")}, "a"},
+	}
+	for _, tc := range cases {
+		got, ok := rollbackTarget(tc.history)
+		if got != tc.want || ok != (tc.want != "") {
+			t.Errorf("%s: got %q, %v; want %q", tc.name, got, ok, tc.want)
+		}
+	}
+}
+
+// setupTestRegistry skips the test when the test database isn't running. Models registered
+// through the returned function are removed afterwards and the active model is restored.
+func setupTestRegistry(t *testing.T) (*Registry, func(name string) Model) {
+	t.Helper()
+	pool := testutils.SetupTestDB(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		t.Skipf("test database unavailable: %v", err)
+	}
+	registry := New(pool)
+
+	active, _, err := registry.Serving(context.Background())
+	if err != nil {
+		t.Fatalf("Serving failed: %v", err)
+	}
+	var ids []int
+	t.Cleanup(func() {
+		ctx := context.Background()
+		pool.Exec(ctx, `DELETE FROM model_activations WHERE model_id = ANY($1)`, ids)
+		pool.Exec(ctx, `DELETE FROM shadow_predictions WHERE model_id = ANY($1) OR active_model_id = ANY($1)`, ids)
+		pool.Exec(ctx, `DELETE FROM prediction_models WHERE id = ANY($1)`, ids)
+		if active != nil {
+			pool.Exec(ctx, `UPDATE prediction_models SET status = 'active' WHERE id = $1`, active.ID)
+		}
+		pool.Close()
+	})
+
+	prefix := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	register := func(name string) Model {
+		m, err := registry.Register(context.Background(), Model{Version: prefix + "-" + name, EndpointURL: "http://localhost:5001/predict"})
+		if err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+		ids = append(ids, m.ID)
+		return m
+	}
+	return registry, register
+}
+
+func TestPromoteAndRollback(t *testing.T) {
+	registry, register := setupTestRegistry(t)
+	ctx := context.Background()
+	a, b, c := register("a"), register("b"), register("c")
+
+	for _, m := range []Model{a, b, c} {
+		promoted, err := registry.Promote(ctx, m.Version)
+		if err != nil || promoted.Status != StatusActive {
+			t.Fatalf("Promote(%s) = %+v, %v", m.Version, promoted, err)
+		}
+	}
+	if demoted, err := registry.Get(ctx, b.Version); err != nil || demoted.Status != StatusCandidate {
+		t.Errorf("expected the replaced model to be a candidate again, got %+v, %v", demoted, err)
+	}
+
+	for _, want := range []Model{b, a} {
+		back, err := registry.Rollback(ctx)
+		if err != nil {
+			t.Fatalf("Rollback failed: %v", err)
+		}
+		t.Logf("rolled back to %s", back.Version)
+		if back.Version != want.Version {
+			t.Errorf("expected a rollback to %s, got %s", want.Version, back.Version)
+		}
+		active, _, err := registry.Serving(ctx)
+		if err != nil || active == nil || active.Version != want.Version {
+			t.Errorf("expected %s to be active, got %+v, %v", want.Version, active, err)
+		}
+	}
+
+	if _, err := registry.Promote(ctx, "does-not-exist"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package modelregistry
+
+import (
+	"context"
+	"time"
+)
+
+// ShadowPrediction is what a shadow model predicted for a request, next to the active model's answer
+type ShadowPrediction struct {
+	ModelID          int            `json:"-"`
+	ModelVersion     string         `json:"model_version"`
+	ActiveModelID    *int           `json:"-"`
+	ActiveVersion    *string        `json:"active_version,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	Features         map[string]any `json:"features"`
+	Prediction       *int           `json:"prediction,omitempty"`
+	ActivePrediction *int           `json:"active_prediction,omitempty"`
+	Error            *string        `json:"error,omitempty"`
+	LatencyMs        int            `json:"latency_ms"`
+}
+
+// ShadowReport compares a shadow model to the active model over a time window
+type ShadowReport struct {
+	ModelVersion      string   `json:"model_version"`
+	Since             string   `json:"since"`
+	Requests          int      `json:"requests"`
+	Errors            int      `json:"errors"`
+	MeanAbsDifference *float64 `json:"mean_abs_difference,omitempty"`
+	MeanLatencyMs     *float64 `json:"mean_latency_ms,omitempty"`
+}
+
+func (r *Registry) LogShadowPrediction(ctx context.Context, p ShadowPrediction) error {
+	_, err := r.DB.Exec(ctx,
+		`INSERT INTO shadow_predictions (model_id, active_model_id, features, prediction, active_prediction, error, latency_ms)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		p.ModelID, p.ActiveModelID, p.Features, p.Prediction, p.ActivePrediction, p.Error, p.LatencyMs)
+	return err
+}
+
+// ShadowPredictions returns the latest logged outputs of a shadow model
+func (r *Registry) ShadowPredictions(ctx context.Context, version string, limit int) ([]ShadowPrediction, error) {
+	rows, err := r.DB.Query(ctx,
+		`SELECT m.version, a.version, s.created_at, s.features, s.prediction, s.active_prediction, s.error, s.latency_ms
+		 FROM shadow_predictions s
+		 JOIN prediction_models m ON m.id = s.model_id
+		 LEFT JOIN prediction_models a ON a.id = s.active_model_id
+		 WHERE m.version = $1
+		 ORDER BY s.created_at DESC
+		 LIMIT $2`, version, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ShadowPrediction
+	for rows.Next() {
+		var p ShadowPrediction
+		if err := rows.Scan(&p.ModelVersion, &p.ActiveVersion, &p.CreatedAt, &p.Features, &p.Prediction, &p.ActivePrediction, &p.Error, &p.LatencyMs); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *Registry) ShadowReport(ctx context.Context, version string, since time.Time) (ShadowReport, error) {
+	report := ShadowReport{ModelVersion: version, Since: since.Format(time.RFC3339)}
+	err := r.DB.QueryRow(ctx,
+		`SELECT COUNT(*),
+		        COUNT(*) FILTER (WHERE s.error IS NOT NULL),
+		        AVG(ABS(s.prediction - s.active_prediction)),
+		        AVG(s.latency_ms)
+		 FROM shadow_predictions s
+		 JOIN prediction_models m ON m.id = s.model_id
+		 WHERE m.version = $1 AND s.created_at >= $2`, version, since).
+		Scan(&report.Requests, &report.Errors, &report.MeanAbsDifference, &report.MeanLatencyMs)
+	return report, err
+}
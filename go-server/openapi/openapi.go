@@ -0,0 +1,45 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+)
+
+// specYAML describes every route registered in routes.RegisterRoutes
+//
+//go:embed openapi.yaml
+var specYAML []byte
+
+// Spec returns the embedded OpenAPI 3 document, parsed and validated on first use
+var Spec = sync.OnceValues(func() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(specYAML)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return doc, nil
+})
+
+// Handler serves the document as JSON, without the API envelope
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := Spec()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "invalid OpenAPI spec", "err", err)
+			api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(doc)
+	}
+}
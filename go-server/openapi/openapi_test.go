@@ -0,0 +1,35 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSpecIsValid(t *testing.T) {
+	doc, err := Spec()
+	if err != nil {
+		t.Fatalf("embedded spec is invalid: %v", err)
+	}
+	t.Logf("%s %s: %d paths", doc.Info.Title, doc.Info.Version, doc.Paths.Len())
+}
+
+func TestHandlerServesJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler()(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var served struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&served); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if served.OpenAPI != "3.0.3" || served.Paths["/api/v1/surfers/predict"] == nil {
+		t.Errorf("unexpected document: openapi=%q with %d paths", served.OpenAPI, len(served.Paths))
+	}
+}
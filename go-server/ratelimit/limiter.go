@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+)
+
+// Outcomes recorded in metrics.RateLimitDecisions
+const (
+	OutcomeAllowed      = "allowed"
+	OutcomeLimited      = "limited"
+	OutcomeStoreError   = "store_error"
+	OutcomeDuplicate    = "duplicate"
+	OutcomeBodyTooLarge = "body_too_large"
+)
+
+// Pseudo limiter names for decisions that aren't made by a token bucket
+const (
+	DuplicatesName = "duplicates"
+	BodySizeName   = "body_size"
+)
+
+// Limiter is a named token bucket policy applied per key (an IP, a contributor token)
+type Limiter struct {
+	Name  string
+	Rate  float64 // tokens per second
+	Burst int
+
+	store    Store
+	fallback *MemoryStore
+	now      func() time.Time
+}
+
+// NewLimiter allows perMinute requests per key on average, and burst at once
+func NewLimiter(name string, store Store, perMinute float64, burst int) *Limiter {
+	return &Limiter{
+		Name:     name,
+		Rate:     perMinute / 60,
+		Burst:    burst,
+		store:    store,
+		fallback: NewMemoryStore(),
+		now:      time.Now,
+	}
+}
+
+// Allow takes a token for key. If the shared store fails the instance-local fallback decides,
+// so an unavailable database doesn't turn into either an outage or no limit at all.
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, time.Duration) {
+	now := l.now()
+	allowed, retryAfter, err := l.store.Take(ctx, l.Name+":"+key, l.Rate, l.Burst, now)
+	if err != nil {
+		slog.WarnContext(ctx, "rate limit store failed, using in-memory fallback", "limiter", l.Name, "err", err)
+		metrics.RateLimitDecisions.WithLabelValues(l.Name, OutcomeStoreError).Inc()
+		allowed, retryAfter, _ = l.fallback.Take(ctx, l.Name+":"+key, l.Rate, l.Burst, now)
+	}
+
+	if allowed {
+		metrics.RateLimitDecisions.WithLabelValues(l.Name, OutcomeAllowed).Inc()
+	} else {
+		metrics.RateLimitDecisions.WithLabelValues(l.Name, OutcomeLimited).Inc()
+	}
+	return allowed, retryAfter
+}
+
+// Deduplicator rejects the same submission from the same sender within Window
+type Deduplicator struct {
+	Window time.Duration
+
+	store    Store
+	fallback *MemoryStore
+	now      func() time.Time
+}
+
+func NewDeduplicator(store Store, window time.Duration) *Deduplicator {
+	return &Deduplicator{Window: window, store: store, fallback: NewMemoryStore(), now: time.Now}
+}
+
+// Seen records key and reports whether it was already submitted within the window
+func (d *Deduplicator) Seen(ctx context.Context, key string) bool {
+	now := d.now()
+	seen, err := d.store.Remember(ctx, "dup:"+key, d.Window, now)
+	if err != nil {
+		slog.WarnContext(ctx, "duplicate check failed, using in-memory fallback", "err", err)
+		seen, _ = d.fallback.Remember(ctx, "dup:"+key, d.Window, now)
+	}
+	if seen {
+		metrics.RateLimitDecisions.WithLabelValues(DuplicatesName, OutcomeDuplicate).Inc()
+	}
+	return seen
+}
+
+// Forget drops key again, so a submission that wasn't stored can be retried right away
+func (d *Deduplicator) Forget(ctx context.Context, key string) {
+	if err := d.store.Forget(ctx, "dup:"+key); err != nil {
+		slog.WarnContext(ctx, "could not forget submission", "err", err)
+	}
+	d.fallback.Forget(ctx, "dup:"+key)
+}
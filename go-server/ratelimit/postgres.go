@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// refilledSQL is the existing bucket's tokens after refilling since its last request ($2 rate, $3 burst, $4 now)
+const refilledSQL = `LEAST($3::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4 - b.updated_at))::float8, 0) * $2::float8)`
+
+// PostgresStore shares buckets between instances. Each Take is a single atomic upsert.
+type PostgresStore struct {
+	DB *pgxpool.Pool
+}
+
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, time.Duration, error) {
+	var tokens float64
+	var allowed bool
+	err := s.DB.QueryRow(ctx,
+		`INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		 VALUES ($1, $3::float8 - 1, true, $4)
+		 ON CONFLICT (key) DO UPDATE SET
+		   tokens = CASE WHEN `+refilledSQL+` >= 1 THEN `+refilledSQL+` - 1 ELSE `+refilledSQL+` END,
+		   allowed = `+refilledSQL+` >= 1,
+		   updated_at = $4
+		 RETURNING b.tokens, b.allowed`,
+		key, rate, float64(burst), now).Scan(&tokens, &allowed)
+	if err != nil {
+		return false, 0, err
+	}
+	if allowed {
+		return true, 0, nil
+	}
+	return false, waitFor(tokens, rate), nil
+}
+
+func (s *PostgresStore) Remember(ctx context.Context, key string, ttl time.Duration, now time.Time) (bool, error) {
+	// The upsert only returns a row if the key is new or expired
+	var inserted string
+	err := s.DB.QueryRow(ctx,
+		`INSERT INTO rate_limit_seen AS s (key, expires_at) VALUES ($1, $2)
+		 ON CONFLICT (key) DO UPDATE SET expires_at = $2 WHERE s.expires_at <= $3
+		 RETURNING key`,
+		key, now.Add(ttl), now).Scan(&inserted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, nil
+	}
+	return false, err
+}
+
+func (s *PostgresStore) Forget(ctx context.Context, key string) error {
+	_, err := s.DB.Exec(ctx, `DELETE FROM rate_limit_seen WHERE key = $1`, key)
+	return err
+}
+
+// Sweep deletes idle buckets and expired submissions every interval until ctx is cancelled.
+// interval must be longer than it takes a bucket to refill.
+func (s *PostgresStore) Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Buckets idle for an interval have refilled, so dropping them changes nothing
+			_, err := s.DB.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < $1`, time.Now().Add(-interval))
+			if err == nil {
+				_, err = s.DB.Exec(ctx, `DELETE FROM rate_limit_seen WHERE expires_at < $1`, time.Now())
+			}
+			if err != nil && ctx.Err() == nil {
+				slog.Warn("rate limit sweep failed", "err", err)
+			}
+		}
+	}
+}
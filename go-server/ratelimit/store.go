@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store keeps token buckets and recently seen submissions. MemoryStore is per instance,
+// PostgresStore is shared by all instances using the same database.
+type Store interface {
+	// Take removes one token from the bucket key (refilled at rate tokens/s, holding at most burst)
+	// and reports how long to wait for the next token if the bucket is empty.
+	Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (allowed bool, retryAfter time.Duration, err error)
+	// Remember records key for ttl and reports whether it was already recorded
+	Remember(ctx context.Context, key string, ttl time.Duration, now time.Time) (seen bool, err error)
+	// Forget drops a key recorded by Remember
+	Forget(ctx context.Context, key string) error
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	rate    float64
+	burst   int
+}
+
+// MemoryStore is the default in-process store. Idle entries are swept on the go.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	seen      map[string]time.Time // key → expiry
+	lastSweep time.Time
+}
+
+// sweepInterval bounds how often idle buckets and expired submissions are dropped
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+		seen:    map[string]time.Time{},
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, rate float64, burst int, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.rate, b.burst = rate, burst
+	b.tokens = refill(b.tokens, now.Sub(b.updated), rate, burst)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, waitFor(b.tokens, rate), nil
+}
+
+func (s *MemoryStore) Remember(_ context.Context, key string, ttl time.Duration, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expires, ok := s.seen[key]; ok && now.Before(expires) {
+		return true, nil
+	}
+	s.seen[key] = now.Add(ttl)
+	return false, nil
+}
+
+func (s *MemoryStore) Forget(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.seen, key)
+	return nil
+}
+
+// sweepLocked drops buckets that have refilled completely (they equal a new bucket) and expired submissions
+func (s *MemoryStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if refill(b.tokens, now.Sub(b.updated), b.rate, b.burst) >= float64(b.burst) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, expires := range s.seen {
+		if !now.Before(expires) {
+			delete(s.seen, key)
+		}
+	}
+}
+
+func refill(tokens float64, elapsed time.Duration, rate float64, burst int) float64 {
+	return math.Min(float64(burst), tokens+elapsed.Seconds()*rate)
+}
+
+// waitFor is the time until the bucket holds a whole token again
+func waitFor(tokens, rate float64) time.Duration {
+	return time.Duration((1 - tokens) / rate * float64(time.Second))
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Date(2025, 6, 5, 12, 0, 0, 0, time.UTC)
+	rate := 6.0 / 60 // one token every 10s
+
+	for i := 0; i < 3; i++ {
+		if allowed, _, _ := store.Take(ctx, "1.2.3.4", rate, 3, now); !allowed {
+			t.Fatalf("expected request %d of the burst to be allowed", i+1)
+		}
+	}
+
+	allowed, retryAfter, _ := store.Take(ctx, "1.2.3.4", rate, 3, now)
+	t.Logf("4th request: allowed=%v retry after %v", allowed, retryAfter)
+	if allowed || retryAfter != 10*time.Second {
+		t.Errorf("expected the 4th request to wait 10s, got allowed=%v retryAfter=%v", allowed, retryAfter)
+	}
+
+	if allowed, _, _ := store.Take(ctx, "5.6.7.8", rate, 3, now); !allowed {
+		t.Error("expected other keys to have their own bucket")
+	}
+	if allowed, _, _ := store.Take(ctx, "1.2.3.4", rate, 3, now.Add(10*time.Second)); !allowed {
+		t.Error("expected a token after 10s")
+	}
+}
+
+func TestMemoryStoreRemember(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	if seen, _ := store.Remember(ctx, "entry", time.Minute, now); seen {
+		t.Fatal("expected first submission to be new")
+	}
+	if seen, _ := store.Remember(ctx, "entry", time.Minute, now.Add(30*time.Second)); !seen {
+		t.Error("expected repeated submission within the window to be seen")
+	}
+	if seen, _ := store.Remember(ctx, "entry", time.Minute, now.Add(2*time.Minute)); seen {
+		t.Error("expected submission after the window to be new again")
+	}
+
+	store.Forget(ctx, "entry")
+	if seen, _ := store.Remember(ctx, "entry", time.Minute, now.Add(2*time.Minute)); seen {
+		t.Error("expected a forgotten submission to be new again")
+	}
+}
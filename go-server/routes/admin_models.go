@@ -0,0 +1,171 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/modelregistry"
+)
+
+// registerModelAdminRoutes adds the model registry admin API (paths relative to the API prefix). All routes require the admin token.
+func registerModelAdminRoutes(mux Router, registry *modelregistry.Registry, guard func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("/admin/models", guard(handleModels(registry)))
+	mux.HandleFunc("/admin/models/rollback", guard(postOnly(handleModelRollback(registry))))
+	mux.HandleFunc("/admin/models/{version}/promote", guard(postOnly(handleModelPromote(registry))))
+	mux.HandleFunc("/admin/models/{version}/status", guard(postOnly(handleModelStatus(registry))))
+	mux.HandleFunc("/admin/models/{version}/shadow", guard(handleShadowReport(registry)))
+}
+
+func handleModels(registry *modelregistry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			models, err := registry.List(r.Context())
+			if err != nil {
+				writeModelError(w, r, err)
+				return
+			}
+			api.JSON(w, r, http.StatusOK, models)
+
+		case http.MethodPost:
+			var input modelregistry.Model
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, "Invalid input")
+				return
+			}
+			if input.Version == "" || input.EndpointURL == "" {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, "version and endpoint_url are required")
+				return
+			}
+			model, err := registry.Register(r.Context(), input)
+			if err != nil {
+				writeModelError(w, r, err)
+				return
+			}
+			slog.InfoContext(r.Context(), "registered model", "model_version", model.Version)
+			api.JSON(w, r, http.StatusCreated, model)
+
+		default:
+			api.MethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+		}
+	}
+}
+
+func handleModelPromote(registry *modelregistry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		model, err := registry.Promote(r.Context(), r.PathValue("version"))
+		if err != nil {
+			writeModelError(w, r, err)
+			return
+		}
+		slog.InfoContext(r.Context(), "promoted model", "model_version", model.Version)
+		api.JSON(w, r, http.StatusOK, model)
+	}
+}
+
+func handleModelRollback(registry *modelregistry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		model, err := registry.Rollback(r.Context())
+		if err != nil {
+			writeModelError(w, r, err)
+			return
+		}
+		slog.InfoContext(r.Context(), "rolled back model", "model_version", model.Version)
+		api.JSON(w, r, http.StatusOK, model)
+	}
+}
+
+// handleModelStatus puts a model in or out of shadow mode, or retires it
+func handleModelStatus(registry *modelregistry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, "Invalid input")
+			return
+		}
+		model, err := registry.SetStatus(r.Context(), r.PathValue("version"), input.Status)
+		if err != nil {
+			writeModelError(w, r, err)
+			return
+		}
+		slog.InfoContext(r.Context(), "changed model status", "model_version", model.Version, "status", model.Status)
+		api.JSON(w, r, http.StatusOK, model)
+	}
+}
+
+// handleShadowReport compares a shadow model to the active one. Query: since (duration, default 24h), limit
+func handleShadowReport(registry *modelregistry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			api.MethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		since := 24 * time.Hour
+		if s := r.URL.Query().Get("since"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, "Invalid since, expected a duration like 24h")
+				return
+			}
+			since = d
+		}
+		limit := 50
+		if s := r.URL.Query().Get("limit"); s != "" {
+			l, err := strconv.Atoi(s)
+			if err != nil || l < 1 || l > 1000 {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, "Invalid limit, expected 1-1000")
+				return
+			}
+			limit = l
+		}
+
+		version := r.PathValue("version")
+		if _, err := registry.Get(r.Context(), version); err != nil {
+			writeModelError(w, r, err)
+			return
+		}
+		report, err := registry.ShadowReport(r.Context(), version, time.Now().Add(-since))
+		if err != nil {
+			writeModelError(w, r, err)
+			return
+		}
+		predictions, err := registry.ShadowPredictions(r.Context(), version, limit)
+		if err != nil {
+			writeModelError(w, r, err)
+			return
+		}
+
+		api.JSON(w, r, http.StatusOK, ShadowReportResponse{Report: report, Predictions: predictions})
+	}
+}
+
+func writeModelError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, modelregistry.ErrNotFound):
+		api.Error(w, r, http.StatusNotFound, api.CodeNotFound, err.Error())
+	case errors.Is(err, modelregistry.ErrInvalidStatus):
+		api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, "status must be candidate, shadow or retired (use promote to activate)")
+	case errors.Is(err, modelregistry.ErrNoRollback), errors.Is(err, modelregistry.ErrVersionExists):
+		api.Error(w, r, http.StatusConflict, api.CodeConflict, err.Error())
+	default:
+		slog.ErrorContext(r.Context(), "model registry failed", "err", err)
+		api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "")
+	}
+}
+
+func postOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			api.MethodNotAllowed(w, r, http.MethodPost)
+			return
+		}
+		handler(w, r)
+	}
+}
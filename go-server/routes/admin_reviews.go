@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
+)
+
+func handleReviewQueue(service *surferdata.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			api.MethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		queue, err := service.GetReviewQueue(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "review queue failed", "err", err)
+			api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "Failed to fetch review queue")
+			return
+		}
+		api.JSON(w, r, http.StatusOK, queue)
+	}
+}
+
+// handleReviewDecision takes {"decision": "accept" | "reject"} for one entry
+func handleReviewDecision(service *surferdata.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, "Invalid id")
+			return
+		}
+		var input struct {
+			Decision string `json:"decision"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil || (input.Decision != "accept" && input.Decision != "reject") {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, `Invalid input, expected {"decision": "accept" | "reject"}`)
+			return
+		}
+
+		status, err := service.ReviewEntry(r.Context(), id, input.Decision == "accept")
+		if errors.Is(err, surferdata.ErrEntryNotFound) {
+			api.Error(w, r, http.StatusNotFound, api.CodeNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "review decision failed", "entry_id", id, "err", err)
+			api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "Failed to save review")
+			return
+		}
+
+		slog.InfoContext(r.Context(), "entry reviewed", "entry_id", id, "review_status", status)
+		api.JSON(w, r, http.StatusOK, ReviewDecisionResponse{ID: id, ReviewStatus: status})
+	}
+}
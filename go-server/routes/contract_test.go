@@ -0,0 +1,252 @@
+package routes
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/modelregistry"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/openapi"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/testutils"
+)
+
+// patternRecorder collects the patterns RegisterRoutes registers
+type patternRecorder struct {
+	patterns []string
+}
+
+func (p *patternRecorder) HandleFunc(pattern string, _ func(http.ResponseWriter, *http.Request)) {
+	p.patterns = append(p.patterns, pattern)
+}
+
+func loadContractConfig(t *testing.T) {
+	t.Helper()
+	testutils.LoadTestConfig(t)
+	if err := config.LoadWeatherConfig(); err != nil {
+		t.Fatalf("Failed to load weather config: %v", err)
+	}
+	if err := config.LoadRateLimitConfig(); err != nil {
+		t.Fatalf("Failed to load rate limit config: %v", err)
+	}
+}
+
+func specRouter(t *testing.T) routers.Router {
+	t.Helper()
+	spec, err := openapi.Spec()
+	if err != nil {
+		t.Fatalf("OpenAPI spec is invalid: %v", err)
+	}
+	router, err := legacy.NewRouter(spec)
+	if err != nil {
+		t.Fatalf("Failed to build router from spec: %v", err)
+	}
+	return router
+}
+
+func TestRegisteredRoutesMatchOpenAPISpec(t *testing.T) {
+	loadContractConfig(t)
+	spec, err := openapi.Spec()
+	if err != nil {
+		t.Fatalf("OpenAPI spec is invalid: %v", err)
+	}
+
+	recorder := &patternRecorder{}
+	RegisterRoutes(recorder, nil)
+
+	registered := map[string]bool{}
+	for _, pattern := range recorder.patterns {
+		// Patterns may start with a method ("GET /path")
+		path := pattern[strings.Index(pattern, "/"):]
+		switch {
+		case path == api.Prefix+"/":
+			continue // answers unknown versioned routes with a problem
+		case strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, api.Prefix+"/"):
+			// Deprecated aliases take the requests of their versioned successor
+			if !slices.Contains(recorder.patterns, api.Successor(path)) {
+				t.Errorf("deprecated route %q has no successor %q", pattern, api.Successor(path))
+			}
+			continue
+		}
+		registered[path] = true
+		if spec.Paths.Find(path) == nil {
+			t.Errorf("route %q is not described in the OpenAPI spec", pattern)
+		}
+	}
+	for _, path := range spec.Paths.InMatchingOrder() {
+		if !registered[path] {
+			t.Errorf("OpenAPI spec describes %q, but no such route is registered", path)
+		}
+	}
+
+	paths := make([]string, 0, len(registered))
+	for path := range registered {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	t.Logf("%d routes: %s", len(paths), strings.Join(paths, ", "))
+}
+
+type contractCase struct {
+	method, target string
+	body           string
+	handler        http.HandlerFunc
+}
+
+func init() {
+	// NDJSON exports are plain text to the spec, like CSV
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.RegisteredBodyDecoder("text/plain"))
+}
+
+// checkContract runs each case and validates the response against the spec, including its status
+func checkContract(t *testing.T, cases []contractCase) {
+	t.Helper()
+	router := specRouter(t)
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.target, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			route, pathParams, err := router.FindRoute(req)
+			if err != nil {
+				t.Fatalf("request not in spec: %v", err)
+			}
+			for name, value := range pathParams {
+				req.SetPathValue(name, value)
+			}
+
+			rec := httptest.NewRecorder()
+			tc.handler(rec, req)
+			t.Logf("%d %s", rec.Code, rec.Header().Get("Content-Type"))
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: pathParams,
+					Route:      route,
+				},
+				Status:  rec.Code,
+				Header:  rec.Header(),
+				Body:    io.NopCloser(rec.Body),
+				Options: &openapi3filter.Options{IncludeResponseStatus: true},
+			})
+			if err != nil {
+				t.Errorf("response diverges from the OpenAPI spec: %v", err)
+			}
+		})
+	}
+}
+
+func TestConditionHandlersMatchOpenAPISpec(t *testing.T) {
+	loadContractConfig(t)
+	fixture := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "../conditions/testdata/openmeteo_forecast.json")
+	}))
+	defer fixture.Close()
+
+	airService := conditions.NewAirService(conditions.NewOpenMeteoClient(fixture.URL, config.Spot.Latitude, config.Spot.Longitude, 2))
+	waterService := &conditions.MockWaterService{}
+
+	checkContract(t, []contractCase{
+		{method: http.MethodGet, target: "/api/v1/conditions/weather", handler: withHTTPCache(handleWeather(airService))},
+		{method: http.MethodGet, target: "/api/v1/conditions/weather/forecast", handler: withHTTPCache(handleWeatherForecast(airService))},
+		{method: http.MethodGet, target: "/api/v1/conditions/weather/forecast?temperature_unit=fahrenheit&wind_speed_unit=kn", handler: withHTTPCache(handleWeatherForecast(airService))},
+		{method: http.MethodGet, target: "/api/v1/conditions/daylight?date=2025-06-21", handler: withETag(handleDaylight())},
+		{method: http.MethodGet, target: "/api/v1/conditions/daylight?date=21.06.2025", handler: withETag(handleDaylight())},
+		{method: http.MethodGet, target: "/api/v1/conditions/water/temperature", handler: withHTTPCache(handleWaterTemperature(waterService))},
+		{method: http.MethodGet, target: "/api/v1/conditions/water/history", handler: withHTTPCache(HandleWaterHistory(waterService))},
+		{method: http.MethodGet, target: "/api/v1/conditions/water/history?format=csv&from=2025-01-01", handler: withHTTPCache(HandleWaterHistory(waterService))},
+		{method: http.MethodGet, target: "/api/v1/conditions/water/history?format=ndjson", handler: withHTTPCache(HandleWaterHistory(waterService))},
+		{method: http.MethodGet, target: "/api/v1/conditions/water/history?from=2025-02-01&to=2025-01-01", handler: withHTTPCache(HandleWaterHistory(waterService))},
+		{method: http.MethodGet, target: "/api/v1/conditions/water", handler: withHTTPCache(handleWaterLevelAndFlow(waterService))},
+		{method: http.MethodGet, target: "/healthz", handler: handleHealthz()},
+		{method: http.MethodGet, target: "/api/v1/openapi.json", handler: openapi.Handler()},
+	})
+}
+
+// connectTestDB skips the test when the test database isn't running
+func connectTestDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	pool := testutils.SetupTestDB(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		t.Skipf("test database unavailable: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestDatabaseHandlersMatchOpenAPISpec(t *testing.T) {
+	loadContractConfig(t)
+	pool := connectTestDB(t)
+
+	fixture := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "../conditions/testdata/openmeteo_forecast.json")
+	}))
+	defer fixture.Close()
+
+	airService := conditions.NewAirService(conditions.NewOpenMeteoClient(fixture.URL, config.Spot.Latitude, config.Spot.Longitude, 2))
+	waterService := &conditions.MockWaterService{}
+	service := surferdata.NewService(pool, waterService, airService)
+	registry := modelregistry.New(pool)
+
+	checkContract(t, []contractCase{
+		{method: http.MethodGet, target: "/api/v1/surfers", handler: withETag(handleSurferEntries(service))},
+		{method: http.MethodGet, target: "/api/v1/surfers?format=csv&from=2025-01-01", handler: withETag(handleSurferEntries(service))},
+		{method: http.MethodGet, target: "/api/v1/surfers?format=ndjson&to=2025-12-31", handler: withETag(handleSurferEntries(service))},
+		{method: http.MethodPost, target: "/api/v1/surfers", body: `{"count": -1}`, handler: withETag(handleSurferEntries(service))},
+		{method: http.MethodGet, target: "/api/v1/surfers/predict?hour=14&water_temperature=16.5", handler: withDataAge(handlePrediction(airService, service, waterService))},
+		{method: http.MethodGet, target: "/api/v1/surfers/stats", handler: withETag(handleCrowdStats(service))},
+		{method: http.MethodGet, target: "/api/v1/surfers/stats?from=2025-06-01&to=2025-06-30", handler: withETag(handleCrowdStats(service))},
+		{method: http.MethodGet, target: "/api/v1/surfers/stats?from=2025-07-01&to=2025-06-01", handler: withETag(handleCrowdStats(service))},
+		{method: http.MethodGet, target: "/api/v1/admin/models", handler: handleModels(registry)},
+		{method: http.MethodGet, target: "/api/v1/admin/models/does-not-exist/shadow", handler: handleShadowReport(registry)},
+		{method: http.MethodGet, target: "/api/v1/admin/reviews", handler: handleReviewQueue(service)},
+		{method: http.MethodPost, target: "/api/v1/admin/reviews/0", body: `{"decision": "accept"}`, handler: handleReviewDecision(service)},
+		{method: http.MethodGet, target: "/readyz", handler: handleReadyz(readinessChecks(pool))},
+	})
+}
+
+func TestDeprecatedAliasesServeUnwrappedData(t *testing.T) {
+	loadContractConfig(t)
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/conditions/daylight?date=2025-06-21", nil))
+	t.Logf("%d %s Deprecation=%q Link=%q", rec.Code, strings.TrimSpace(rec.Body.String()), rec.Header().Get("Deprecation"), rec.Header().Get("Link"))
+	if rec.Header().Get("Deprecation") == "" || !strings.HasPrefix(rec.Body.String(), `{"date":"2025-06-21"`) {
+		t.Errorf("expected the unwrapped sun times with a Deprecation header")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/conditions/daylight?date=2025-06-21", nil))
+	if rec.Header().Get("Deprecation") != "" || !strings.HasPrefix(rec.Body.String(), `{"data":{"date":"2025-06-21"`) {
+		t.Errorf("expected enveloped sun times without a Deprecation header, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/conditions/tide", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("expected a not_found problem for an unknown route, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+)
+
+// withDataAge records which cached sources the handler used and annotates the
+// response with the age of the oldest value served (Age) and the cache status (X-Cache).
+func withDataAge(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, trace := conditions.WithCacheTrace(r.Context())
+		handler(&dataAgeWriter{ResponseWriter: w, trace: trace}, r.WithContext(ctx))
+	}
+}
+
+// withHTTPCache is withDataAge for responses that HTTP caches (service worker, CDN) may keep.
+// Cache-Control lets them keep a response for as long as the upstream values in it stay fresh
+// in our own cache, whose TTLs follow how often each source publishes. Revalidation is by ETag.
+func withHTTPCache(handler http.HandlerFunc) http.HandlerFunc {
+	return withETag(func(w http.ResponseWriter, r *http.Request) {
+		ctx, trace := conditions.WithCacheTrace(r.Context())
+		handler(&dataAgeWriter{ResponseWriter: w, trace: trace, cacheControl: true}, r.WithContext(ctx))
+	})
+}
+
+type dataAgeWriter struct {
+	http.ResponseWriter
+	trace        *conditions.CacheTrace
+	cacheControl bool
+	wroteHeader  bool
+}
+
+func (w *dataAgeWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if age, cacheStatus, ok := w.trace.Summary(); ok {
+			w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+			w.Header().Set("X-Cache", string(cacheStatus))
+			if w.cacheControl && status == http.StatusOK {
+				// Caches count max-age from the Age they are given, so add what's left of the TTL to it
+				fresh, _ := w.trace.FreshFor()
+				w.Header().Set("Cache-Control", publicMaxAge(age+fresh))
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *dataAgeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *dataAgeWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
+)
+
+// handleTrainingExport serves the ML feature table, see surferdata.TrainingRow.
+// Query: format (csv|jsonl|parquet), from, to (YYYY-MM-DD or RFC 3339), split (train|test), test_ratio, seed
+func handleTrainingExport(service *surferdata.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			api.MethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+
+		query := r.URL.Query()
+		format, err := surferdata.ParseExportFormat(query.Get("format"))
+		if err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+			return
+		}
+
+		filter := surferdata.TrainingFilter{
+			Split:     query.Get("split"),
+			TestRatio: surferdata.DefaultTestRatio,
+			Seed:      query.Get("seed"),
+		}
+		if filter.From, err = surferdata.ParseTimeBound(query.Get("from"), false); err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+			return
+		}
+		if filter.To, err = surferdata.ParseTimeBound(query.Get("to"), true); err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+			return
+		}
+		if ratio := query.Get("test_ratio"); ratio != "" {
+			if filter.TestRatio, err = strconv.ParseFloat(ratio, 64); err != nil {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, "Invalid test_ratio")
+				return
+			}
+		}
+		if err := filter.Validate(); err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+			return
+		}
+
+		rows, err := service.GetTrainingData(r.Context(), filter)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "training export failed", "err", err)
+			api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "Failed to export training data")
+			return
+		}
+
+		filename := fmt.Sprintf("training_%s.%s", time.Now().Format("20060102"), format)
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		if err := surferdata.WriteTrainingData(w, format, rows); err != nil {
+			slog.ErrorContext(r.Context(), "writing training export failed", "format", format, "err", err)
+		}
+	}
+}
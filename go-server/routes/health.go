@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/db"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/health"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+)
+
+// handleHealthz only tells that the process is alive and serving
+func handleHealthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": string(health.StatusOK)})
+	}
+}
+
+// handleReadyz runs the dependency checks: 503 if a critical one is down, 200 otherwise
+// (possibly "degraded" when an upstream is failing or stale)
+func handleReadyz(checks []health.Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := health.Run(r.Context(), checks)
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, status, report)
+	}
+}
+
+// readinessChecks: the database and its schema are critical, upstreams are not since
+// the API can serve cached or partial data without them. Upstreams are considered stale
+// once their last success is older than their cache may serve it.
+func readinessChecks(pool *pgxpool.Pool) []health.Check {
+	checks := []health.Check{
+		{Name: "database", Critical: true, Run: checkDatabase(pool)},
+		{Name: "migrations", Critical: true, Run: checkMigrations(pool)},
+	}
+	for source, policy := range map[string]conditions.CachePolicy{
+		conditions.SourceOpenMeteo:  conditions.WeatherCachePolicy,
+		conditions.SourceGKD:        conditions.WaterTemperatureCachePolicy,
+		conditions.SourcePegelAlarm: conditions.WaterLevelCachePolicy,
+		conditions.SourceHND:        conditions.WaterHistoryCachePolicy,
+	} {
+		checks = append(checks, health.Check{Name: source, Run: checkUpstream(source, policy.MaxStale)})
+	}
+	// Only called for predictions, so only its last call counts, not its age
+	checks = append(checks, health.Check{Name: metrics.ProviderMLService, Run: checkUpstream(metrics.ProviderMLService, 0)})
+	return checks
+}
+
+func checkDatabase(pool *pgxpool.Pool) func(ctx context.Context) health.Result {
+	return func(ctx context.Context) health.Result {
+		started := time.Now()
+		if err := pool.Ping(ctx); err != nil {
+			return health.Result{Status: health.StatusDown, Message: err.Error()}
+		}
+		return health.Result{Status: health.StatusOK, Details: map[string]int64{"ping_ms": time.Since(started).Milliseconds()}}
+	}
+}
+
+func checkMigrations(pool *pgxpool.Pool) func(ctx context.Context) health.Result {
+	return func(ctx context.Context) health.Result {
+		status, err := db.GetMigrationStatus(ctx, pool)
+		if err != nil {
+			return health.Result{Status: health.StatusDown, Message: err.Error()}
+		}
+
+		result := health.Result{Status: health.StatusOK, Details: status}
+		switch {
+		case status.Failed:
+			result.Status, result.Message = health.StatusDown, "a migration failed, run flyway repair"
+		case status.Applied < status.Expected:
+			result.Status, result.Message = health.StatusDown, fmt.Sprintf("schema is at V%d, this build needs V%d", status.Applied, status.Expected)
+		case status.Applied > status.Expected:
+			// A newer instance migrated already; additive migrations keep this one working
+			result.Status, result.Message = health.StatusDegraded, fmt.Sprintf("schema is at V%d, newer than this build (V%d)", status.Applied, status.Expected)
+		}
+		return result
+	}
+}
+
+func checkUpstream(name string, maxAge time.Duration) func(ctx context.Context) health.Result {
+	return func(context.Context) health.Result {
+		return health.Upstreams.Freshness(name, maxAge)
+	}
+}
+
+// writeJSON writes v as is: probes aren't part of the versioned API, so there's no envelope
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+)
+
+func TestHTTPCacheRevalidatesWithETag(t *testing.T) {
+	handler := withHTTPCache(handleWaterTemperature(conditions.NewCachedWaterService(&conditions.MockWaterService{})))
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/conditions/water/temperature", nil))
+	etag := rec.Header().Get("ETag")
+	t.Logf("%d ETag=%s Cache-Control=%q", rec.Code, etag, rec.Header().Get("Cache-Control"))
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", rec.Code, etag)
+	}
+	// A freshly fetched GKD value may be reused for the whole TTL
+	if got, want := rec.Header().Get("Cache-Control"), publicMaxAge(conditions.WaterTemperatureCachePolicy.TTL); got != want {
+		t.Errorf("Cache-Control = %q, want %q", got, want)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/conditions/water/temperature", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected an empty 304 for a matching ETag, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("ETag") != etag || rec.Header().Get("Cache-Control") == "" {
+		t.Errorf("expected the 304 to carry ETag and Cache-Control, got %v", rec.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/conditions/water/temperature", nil)
+	req.Header.Set("If-None-Match", `"outdated"`)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+		t.Errorf("expected the full response for an outdated ETag, got %d", rec.Code)
+	}
+}
+
+func TestETagOnlyForSuccessfulReads(t *testing.T) {
+	handler := withETag(handleDaylight())
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/conditions/daylight?date=not-a-date", nil))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("ETag") != "" {
+		t.Errorf("expected a 400 without ETag, got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/conditions/daylight?date=2025-06-21", nil))
+	if rec.Header().Get("Cache-Control") != publicMaxAge(24*time.Hour) {
+		t.Errorf("expected past dates to be cacheable for a day, got %q", rec.Header().Get("Cache-Control"))
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abc"`
+	for header, want := range map[string]bool{
+		`"abc"`:          true,
+		`W/"abc"`:        true,
+		`"xyz", "abc"`:   true,
+		`*`:              true,
+		`"xyz"`:          false,
+		``:               false,
+		`"abc-modified"`: false,
+	} {
+		if got := etagMatches(header, etag); got != want {
+			t.Errorf("etagMatches(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
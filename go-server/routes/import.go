@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
+)
+
+// handleSurferImport imports surfer entries from a CSV file (text/csv) or a JSON array
+// (application/json) and reports on every row, see surferdata.ImportEntries.
+// Query: dry_run (true|false)
+func handleSurferImport(service *surferdata.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dryRun := false
+		if raw := r.URL.Query().Get("dry_run"); raw != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(raw); err != nil {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, "Invalid dry_run, expected true or false")
+				return
+			}
+		}
+
+		var format surferdata.ImportFormat
+		switch mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType {
+		case "text/csv":
+			format = surferdata.ImportCSV
+		case "application/json":
+			format = surferdata.ImportJSON
+		default:
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, "Content-Type must be text/csv or application/json")
+			return
+		}
+
+		body := http.MaxBytesReader(w, r.Body, int64(config.RateLimit.MaxImportBytes))
+		report, err := service.ImportEntries(r.Context(), body, format, dryRun)
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			api.Error(w, r, http.StatusRequestEntityTooLarge, api.CodeBodyTooLarge, "Import too large")
+			return
+		case errors.Is(err, surferdata.ErrInvalidImport):
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, err.Error())
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "surfer import failed", "err", err)
+			api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "Failed to import entries")
+			return
+		}
+
+		slog.InfoContext(r.Context(), "imported surfer entries",
+			"format", format, "dry_run", dryRun, "accepted", report.Accepted, "rejected", report.Rejected)
+		api.JSON(w, r, http.StatusOK, report)
+	}
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/modelregistry"
+)
+
+// Response bodies; each one is described under the same name in the OpenAPI spec
+
+type WeatherResponse struct {
+	AirTemperature   conditions.Reading[float64] `json:"air_temperature"`
+	WeatherCondition conditions.Reading[int]     `json:"weather_condition"`
+}
+
+type WaterLevelAndFlowResponse struct {
+	WaterLevel conditions.Reading[float64] `json:"water_level"`
+	WaterFlow  conditions.Reading[float64] `json:"water_flow"`
+}
+
+type ForecastResponse struct {
+	*conditions.WeatherForecast
+	Stale bool `json:"stale"`
+}
+
+type EntryCreatedResponse struct {
+	Message      string `json:"message"`
+	ReviewStatus string `json:"review_status"`
+}
+
+type ReviewDecisionResponse struct {
+	ID           int    `json:"id"`
+	ReviewStatus string `json:"review_status"`
+}
+
+type ShadowReportResponse struct {
+	Report      modelregistry.ShadowReport       `json:"report"`
+	Predictions []modelregistry.ShadowPrediction `json:"predictions"`
+}
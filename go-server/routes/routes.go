@@ -1,78 +1,215 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/middleware"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/modelregistry"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/openapi"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/ratelimit"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
 )
 
-// RegisterRoutes registers all API routes
-func RegisterRoutes(db *pgxpool.Pool) {
-	airService := conditions.NewAirService()
-	waterService := conditions.NewWaterService()
+// Worker is a background job started alongside the server. It must return once ctx is cancelled.
+type Worker func(ctx context.Context)
+
+// Router is the part of *http.ServeMux the routes are registered with
+type Router interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// versionedRouter registers each API route under api.Prefix and, as a deprecated alias, under /api
+type versionedRouter struct {
+	mux Router
+}
+
+func (v versionedRouter) HandleFunc(path string, handler func(http.ResponseWriter, *http.Request)) {
+	v.mux.HandleFunc(api.Prefix+path, handler)
+	v.mux.HandleFunc("/api"+path, api.Deprecated(handler))
+}
+
+// RegisterRoutes registers all API routes on mux and returns the background workers they rely on.
+// Every route must be described in the OpenAPI spec (openapi/openapi.yaml).
+func RegisterRoutes(mux Router, db *pgxpool.Pool) []Worker {
+	openMeteo := conditions.NewOpenMeteoClient(config.Weather.OpenMeteoURL, config.Spot.Latitude, config.Spot.Longitude, config.Weather.ForecastDays)
+	airService := conditions.NewCachedAirService(conditions.NewAirService(openMeteo))
+	waterService := conditions.NewCachedWaterService(conditions.NewWaterService())
 	surferService := surferdata.NewService(db, waterService, airService)
-	http.HandleFunc("/api/conditions/weather", middleware.WithCORS(handleWeather(airService)))
-	http.HandleFunc("/api/conditions/water/temperature", middleware.WithCORS(handleWaterTemperature(waterService)))
-	http.HandleFunc("/api/conditions/water/history", middleware.WithCORS(HandleWaterHistory(waterService)))
-	http.HandleFunc("/api/conditions/water", middleware.WithCORS(handleWaterLevelAndFlow(waterService)))
-	http.HandleFunc("/api/surfers", middleware.WithCORS(handleSurferEntries(surferService)))
-	http.HandleFunc("/api/surfers/predict", middleware.WithCORS(handlePrediction(airService, surferService, waterService)))
+	surferService.Models = modelregistry.New(db)
+	budgets := config.Server
+	v1 := versionedRouter{mux}
+	v1.HandleFunc("/conditions/weather", middleware.WithTimeout(budgets.ConditionsTimeout, withHTTPCache(handleWeather(airService))))
+	v1.HandleFunc("/conditions/weather/forecast", middleware.WithTimeout(budgets.ConditionsTimeout, withHTTPCache(handleWeatherForecast(airService))))
+	v1.HandleFunc("/conditions/daylight", withETag(handleDaylight()))
+	v1.HandleFunc("/conditions/water/temperature", middleware.WithTimeout(budgets.WaterTemperatureTimeout, withHTTPCache(handleWaterTemperature(waterService))))
+	v1.HandleFunc("/conditions/water/history", middleware.WithTimeout(budgets.ConditionsTimeout, withHTTPCache(HandleWaterHistory(waterService))))
+	v1.HandleFunc("/conditions/water", middleware.WithTimeout(budgets.ConditionsTimeout, withHTTPCache(handleWaterLevelAndFlow(waterService))))
+	protection, protectionWorkers := newWriteProtection(db)
+	v1.HandleFunc("/surfers", middleware.WithWriteProtection(protection, middleware.WithTimeout(budgets.EntriesTimeout, withETag(handleSurferEntries(surferService)))))
+	v1.HandleFunc("/export/training", middleware.WithTimeout(budgets.ExportTimeout, handleTrainingExport(surferService)))
+	v1.HandleFunc("/surfers/predict", middleware.WithTimeout(budgets.PredictTimeout, withDataAge(handlePrediction(airService, surferService, waterService))))
+	v1.HandleFunc("/surfers/stats", middleware.WithTimeout(budgets.EntriesTimeout, withETag(handleCrowdStats(surferService))))
+
+	admin := func(handler http.HandlerFunc) http.HandlerFunc {
+		return middleware.WithAdminToken(budgets.AdminToken, middleware.WithTimeout(budgets.EntriesTimeout, handler))
+	}
+	registerModelAdminRoutes(v1, surferService.Models, admin)
+	v1.HandleFunc("/admin/reviews", admin(handleReviewQueue(surferService)))
+	v1.HandleFunc("/admin/reviews/{id}", admin(postOnly(handleReviewDecision(surferService))))
+	v1.HandleFunc("/surfers/import", middleware.WithAdminToken(budgets.AdminToken,
+		middleware.WithTimeout(budgets.ImportTimeout, postOnly(handleSurferImport(surferService)))))
+
+	mux.HandleFunc("/healthz", handleHealthz())
+	mux.HandleFunc("/readyz", middleware.WithTimeout(budgets.ReadyTimeout, handleReadyz(readinessChecks(db))))
+
+	metricsHandler := promhttp.Handler().ServeHTTP
+	if budgets.MetricsToken != "" {
+		metricsHandler = middleware.WithAdminToken(budgets.MetricsToken, metricsHandler)
+	}
+	mux.HandleFunc("/metrics", metricsHandler)
+	v1.HandleFunc("/openapi.json", openapi.Handler())
+	mux.HandleFunc(api.Prefix+"/", api.NotFound)
+
+	return append([]Worker{
+		waterService.PollWaterTemperature,
+		surferService.EnrichConditions,
+	}, protectionWorkers...)
+}
+
+// newWriteProtection sets up rate limiting and duplicate detection with the configured store
+func newWriteProtection(db *pgxpool.Pool) (*middleware.WriteProtection, []Worker) {
+	cfg := config.RateLimit
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	var workers []Worker
+	if cfg.Store == "postgres" {
+		pgStore := ratelimit.NewPostgresStore(db)
+		store = pgStore
+		workers = append(workers, func(ctx context.Context) { pgStore.Sweep(ctx, time.Hour) })
+	}
+
+	protection := &middleware.WriteProtection{
+		PerIP:            ratelimit.NewLimiter("ip", store, cfg.IPPerMinute, cfg.IPBurst),
+		PerToken:         ratelimit.NewLimiter("contributor_token", store, cfg.TokenPerMinute, cfg.TokenBurst),
+		Duplicates:       ratelimit.NewDeduplicator(store, cfg.DuplicateWindow),
+		MaxBodyBytes:     int64(cfg.MaxBodyBytes),
+		TrustedProxyHops: cfg.TrustedProxyHops,
+	}
+	protection.AllowContributors(cfg.ContributorTokens)
+	return protection, workers
 }
 
 // -- Handlers --
 
 func handleWaterTemperature(waterService conditions.WaterDataProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		temp, err := waterService.GetLatestWaterTemperature()
+		temp, err := waterService.GetLatestWaterTemperature(r.Context())
 		if err != nil {
-			log.Println("❌", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "could not fetch water temperature", "err", err)
+			api.Error(w, r, http.StatusServiceUnavailable, api.CodeUpstreamUnavailable, "Water temperature is unavailable")
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"water_temperature": temp,
-		})
+		api.JSON(w, r, http.StatusOK, conditions.NewReading(temp.Value, conditions.UnitCelsius, temp.Provenance))
 	}
 }
 
 func handleWeather(airService conditions.AirDataProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		weatherData, err := airService.GetCurrentWeather()
+		weatherData, err := airService.GetCurrentWeather(r.Context())
 		if err != nil {
-			log.Println("❌", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "could not fetch current weather", "err", err)
+			api.Error(w, r, http.StatusServiceUnavailable, api.CodeUpstreamUnavailable, "Weather is unavailable")
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(weatherData)
+		api.JSON(w, r, http.StatusOK, WeatherResponse{
+			AirTemperature:   conditions.NewReading(weatherData.Temp, conditions.UnitCelsius, weatherData.Provenance),
+			WeatherCondition: conditions.NewReading(weatherData.Condition, conditions.UnitWMOCode, weatherData.Provenance),
+		})
+	}
+}
+
+func handleWeatherForecast(airService conditions.AirDataProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		units := conditions.ForecastUnits{
+			Temperature:   queryOrDefault(r, "temperature_unit", config.Weather.TemperatureUnit),
+			WindSpeed:     queryOrDefault(r, "wind_speed_unit", config.Weather.WindSpeedUnit),
+			Precipitation: queryOrDefault(r, "precipitation_unit", config.Weather.PrecipitationUnit),
+		}
+		if err := units.Validate(); err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+			return
+		}
+
+		forecast, err := airService.GetForecast(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "could not fetch forecast", "err", err)
+			api.Error(w, r, http.StatusServiceUnavailable, api.CodeUpstreamUnavailable, "Forecast is unavailable")
+			return
+		}
+
+		converted, err := forecast.InUnits(units)
+		if err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+			return
+		}
+
+		api.JSON(w, r, http.StatusOK, ForecastResponse{converted, converted.Stale(time.Now())})
+	}
+}
+
+func queryOrDefault(r *http.Request, key, fallback string) string {
+	if v := r.URL.Query().Get(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func handleDaylight() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		date := conditions.AtLocalHour(now, 0)
+		// Sun times of a given date never change; today's are valid until midnight
+		maxAge := date.AddDate(0, 0, 1).Sub(now)
+		if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", dateStr, date.Location())
+			if err != nil {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, "Invalid date, expected YYYY-MM-DD")
+				return
+			}
+			date = parsed
+			maxAge = 24 * time.Hour
+		}
+
+		w.Header().Set("Cache-Control", publicMaxAge(maxAge))
+
+		api.JSON(w, r, http.StatusOK, conditions.SunTimes(date, config.Spot.Latitude, config.Spot.Longitude))
 	}
 }
 
 func handleWaterLevelAndFlow(waterService conditions.WaterDataProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		result, err := waterService.GetLatestWaterLevelAndFlow()
+		result, err := waterService.GetLatestWaterLevelAndFlow(r.Context())
 		if err != nil {
-			log.Printf("❌ Failed to get water level/flow: %v", err)
-			http.Error(w, "Failed to get water data", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "could not fetch water level and flow", "err", err)
+			api.Error(w, r, http.StatusServiceUnavailable, api.CodeUpstreamUnavailable, "Failed to get water data")
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]any{
-			"water_level":  result.Level,
-			"water_flow":   result.Flow,
-			"request_date": result.RequestDate,
+		api.JSON(w, r, http.StatusOK, WaterLevelAndFlowResponse{
+			WaterLevel: conditions.NewReading(result.Level, conditions.UnitCentimeters, result.Provenance),
+			WaterFlow:  conditions.NewReading(result.Flow, conditions.UnitCubicMetersSec, result.Provenance),
 		})
 	}
 }
@@ -85,13 +222,14 @@ func handlePrediction(airService conditions.AirDataProvider, service *surferdata
 		conditionStr := r.URL.Query().Get("weather_condition")
 
 		var hour int
-		if hourStr == "" {
-			hour = time.Now().Hour()
+		forHour := hourStr != ""
+		if !forHour {
+			hour = time.Now().In(conditions.SpotLocation()).Hour()
 		} else {
 			var err error
 			hour, err = strconv.Atoi(hourStr)
 			if err != nil {
-				http.Error(w, "Invalid hour", http.StatusBadRequest)
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, "Invalid hour")
 				return
 			}
 		}
@@ -99,52 +237,94 @@ func handlePrediction(airService conditions.AirDataProvider, service *surferdata
 		var waterTemp, airTemp *float64
 		var weatherCondition *int
 
-		// ✅ Use cached water temp
 		if waterTempStr != "" {
 			if t, err := strconv.ParseFloat(waterTempStr, 64); err == nil {
 				waterTemp = &t
 			}
-		} else if latest, err := waterService.GetCachedWaterTemperature(); err == nil {
-			waterTemp = &latest
 		}
-
-		// ✅ Parse airTempStr and conditionStr together
-		if airTempStr != "" && conditionStr != "" {
-			if t, err := strconv.ParseFloat(airTempStr, 64); err == nil {
-				airTemp = &t
-			} else {
-				http.Error(w, "Invalid air_temperature", http.StatusBadRequest)
+		if airTempStr != "" {
+			t, err := strconv.ParseFloat(airTempStr, 64)
+			if err != nil {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, "Invalid air_temperature")
 				return
 			}
-
-			if c, err := strconv.Atoi(conditionStr); err == nil {
-				weatherCondition = &c
-			} else {
-				http.Error(w, "Invalid weather_condition", http.StatusBadRequest)
+			airTemp = &t
+		}
+		if conditionStr != "" {
+			c, err := strconv.Atoi(conditionStr)
+			if err != nil {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, "Invalid weather_condition")
 				return
 			}
-		} else if current, err := airService.GetCurrentWeather(); err == nil {
-			if airTempStr == "" {
-				airTemp = &current.Temp
-			}
-			if conditionStr == "" {
-				weatherCondition = &current.Condition
-			}
-		} else {
-			log.Println("⚠️ Could not fetch current weather:", err)
-			airTemp = nil
-			weatherCondition = nil
+			weatherCondition = &c
 		}
 
-		// ✅ Fetch the water level
-		var waterLevel float64
-		if latestWater, err := waterService.GetLatestWaterLevelAndFlow(); err == nil {
-			waterLevel = latestWater.Level
-		} else {
-			log.Printf("❌ Failed to fetch water level: %v", err)
-			waterLevel = 0 // Fallback to 0 if water level cannot be retrieved
+		// ✅ Fetch whatever wasn't given in parallel, but only for as long as the
+		// conditions budget allows, so the prediction itself still fits the endpoint budget
+		fetchCtx, cancel := context.WithTimeout(r.Context(), config.Server.PredictConditionsTimeout)
+		defer cancel()
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var waterLevel float64 // Fallback to 0 if water level cannot be retrieved
+
+		if waterTemp == nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				latest, err := waterService.GetLatestWaterTemperature(fetchCtx)
+				if err != nil {
+					slog.WarnContext(fetchCtx, "could not fetch water temperature", "err", err)
+					return
+				}
+				mu.Lock()
+				waterTemp = &latest.Value
+				mu.Unlock()
+			}()
 		}
 
+		if airTemp == nil || weatherCondition == nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				forecast, err := airService.GetForecast(fetchCtx)
+				if err != nil {
+					slog.WarnContext(fetchCtx, "could not fetch forecast", "err", err)
+					return
+				}
+				// Use the forecast for the requested hour, the current weather otherwise
+				temp, condition := forecast.Current.Temperature, forecast.Current.WeatherCode
+				if forHour {
+					if h, ok := forecast.TodayAt(time.Now(), hour); ok {
+						temp, condition = h.Temperature, h.WeatherCode
+					}
+				}
+				mu.Lock()
+				if airTemp == nil {
+					airTemp = &temp
+				}
+				if weatherCondition == nil {
+					weatherCondition = &condition
+				}
+				mu.Unlock()
+			}()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latestWater, err := waterService.GetLatestWaterLevelAndFlow(fetchCtx)
+			if err != nil {
+				slog.WarnContext(fetchCtx, "could not fetch water level and flow", "err", err)
+				return
+			}
+			mu.Lock()
+			waterLevel = latestWater.Level
+			mu.Unlock()
+		}()
+
+		wg.Wait()
+
 		var weatherConditionValue int
 		if weatherCondition != nil {
 			weatherConditionValue = *weatherCondition
@@ -152,21 +332,21 @@ func handlePrediction(airService conditions.AirDataProvider, service *surferdata
 			weatherConditionValue = -1 // Default value for unknown weather condition
 		}
 
-		prediction, err := service.PredictSurferCountAdvanced(surferdata.PredictionParams{
+		prediction, err := service.PredictSurferCountAdvanced(r.Context(), surferdata.PredictionParams{
 			Hour:             hour,
+			Time:             conditions.AtLocalHour(time.Now(), hour),
 			WaterTemp:        waterTemp,
 			AirTemp:          airTemp,
 			WeatherCondition: weatherConditionValue,
 			WaterLevel:       waterLevel,
 		})
 		if err != nil {
-			http.Error(w, "Could not compute prediction: "+err.Error(), http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "could not compute prediction", "err", err)
+			api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "Could not compute prediction")
 			return
 		}
 
-		// Return the response as JSON
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(prediction)
+		api.JSON(w, r, http.StatusOK, prediction)
 
 	}
 }
@@ -175,13 +355,33 @@ func handleSurferEntries(service *surferdata.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			entries, err := service.GetAllEntries()
+			w.Header().Add("Vary", "Accept")
+			format, err := negotiateTableFormat(r)
+			if err != nil {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+				return
+			}
+			from, to, err := parseTimeRange(r)
+			if err != nil {
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+				return
+			}
+			filter := surferdata.EntryFilter{From: from, To: to}
+
+			// New reports can come in any time: caches must always revalidate (cheap thanks to the ETag)
+			w.Header().Set("Cache-Control", "no-cache")
+			if format != tableJSON {
+				streamSurferEntries(w, r, service, format, filter)
+				return
+			}
+
+			entries, err := service.GetEntries(r.Context(), filter)
 			if err != nil {
-				http.Error(w, "Failed to fetch entries", http.StatusInternalServerError)
+				slog.ErrorContext(r.Context(), "could not fetch surfer entries", "err", err)
+				api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "Failed to fetch entries")
 				return
 			}
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(entries)
+			api.JSON(w, r, http.StatusOK, entries)
 
 		case http.MethodPost:
 			var input struct {
@@ -191,43 +391,87 @@ func handleSurferEntries(service *surferdata.Service) http.HandlerFunc {
 			}
 
 			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-				http.Error(w, "Invalid input", http.StatusBadRequest)
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, "Invalid input")
 				return
 			}
 			if input.Count < 0 {
-				http.Error(w, "Surfer count must be positive", http.StatusBadRequest)
+				api.Error(w, r, http.StatusBadRequest, api.CodeInvalidBody, "Surfer count must be positive")
 				return
 			}
 
-			if err := service.AddEntry(input.Count, input.Time, input.WaterTemp); err != nil {
-				log.Printf("Failed to add entry: %v", err)
-				http.Error(w, "Failed to save entry", http.StatusInternalServerError)
+			anomaly, err := service.AddEntry(r.Context(), input.Count, input.Time, input.WaterTemp)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "could not add surfer entry", "err", err)
+				api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "Failed to save entry")
 				return
 			}
 
-			w.WriteHeader(http.StatusCreated)
-			json.NewEncoder(w).Encode(map[string]string{"message": "Entry saved"})
+			message := "Entry saved"
+			if anomaly.Status == surferdata.ReviewQuarantined {
+				message = "Entry saved, it will be counted once reviewed"
+			}
+			api.JSON(w, r, http.StatusCreated, EntryCreatedResponse{Message: message, ReviewStatus: anomaly.Status})
 
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			api.MethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
 		}
 	}
 }
 
-func HandleWaterHistory(service *conditions.WaterDataService) http.HandlerFunc {
+// streamSurferEntries writes the entries as CSV or NDJSON while they are read from the database
+func streamSurferEntries(w http.ResponseWriter, r *http.Request, service *surferdata.Service, format tableFormat, filter surferdata.EntryFilter) {
+	table, err := newTableWriter(w, format, "surfer_entries", surferdata.EntryCSVHeader)
+	if err == nil {
+		err = service.StreamEntries(r.Context(), filter, func(e surferdata.SurferEntryResponse) error {
+			return table.Row(e.CSVRecord(), e)
+		})
+	}
+	if err == nil {
+		err = table.Close()
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not export surfer entries", "format", format, "rows", table.rows, "err", err)
+		table.Fail(r, "Failed to fetch entries")
+	}
+}
+
+func HandleWaterHistory(service conditions.WaterDataProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		history, err := service.GetHistoricalWaterLevels()
+		w.Header().Add("Vary", "Accept")
+		format, err := negotiateTableFormat(r)
 		if err != nil {
-			http.Error(w, "Failed to fetch historical water levels", http.StatusInternalServerError)
-			fmt.Println("❌ Scraper error:", err)
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+			return
+		}
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+			return
+		}
+
+		history, err := service.GetHistoricalWaterLevels(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "could not fetch historical water levels", "err", err)
+			api.Error(w, r, http.StatusServiceUnavailable, api.CodeUpstreamUnavailable, "Failed to fetch historical water levels")
+			return
+		}
+		levels := history.Between(from, to)
+		slog.DebugContext(r.Context(), "fetched historical water levels", "entries", len(history.Levels), "selected", len(levels))
+
+		if format == tableJSON {
+			api.JSON(w, r, http.StatusOK, conditions.NewReading(levels, conditions.UnitCentimeters, history.Provenance))
 			return
 		}
-		fmt.Printf("📊 Scraper returned %d entries\n", len(history))
-		// for _, h := range history {
-		// 	fmt.Println("📅", h.DateTime, "📏", h.Value)
-		// }
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(history)
+		table, err := newTableWriter(w, format, "water_levels", conditions.HistoricalWaterLevelCSVHeader)
+		for i := 0; err == nil && i < len(levels); i++ {
+			err = table.Row(levels[i].CSVRecord(), levels[i])
+		}
+		if err == nil {
+			err = table.Close()
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "could not export water levels", "format", format, "err", err)
+		}
 	}
 }
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
+)
+
+// handleCrowdStats serves the surfer counts aggregated for heatmaps, see surferdata.CrowdStats.
+// Query: from, to (YYYY-MM-DD or RFC 3339)
+func handleCrowdStats(service *surferdata.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			api.MethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			api.Error(w, r, http.StatusBadRequest, api.CodeInvalidParameter, err.Error())
+			return
+		}
+
+		stats, err := service.GetCrowdStats(r.Context(), surferdata.EntryFilter{From: from, To: to})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "could not aggregate surfer counts", "err", err)
+			api.Error(w, r, http.StatusInternalServerError, api.CodeInternal, "Failed to compute crowd statistics")
+			return
+		}
+
+		// Changes with every new report, like the entries themselves
+		w.Header().Set("Cache-Control", "no-cache")
+		api.JSON(w, r, http.StatusOK, stats)
+	}
+}
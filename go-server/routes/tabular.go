@@ -0,0 +1,159 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/api"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/surferdata"
+)
+
+// tableFormat is a representation of a list endpoint, see negotiateTableFormat
+type tableFormat string
+
+const (
+	tableJSON   tableFormat = "json"
+	tableCSV    tableFormat = "csv"
+	tableNDJSON tableFormat = "ndjson"
+)
+
+var tableContentTypes = map[tableFormat]string{
+	tableCSV:    "text/csv; charset=utf-8",
+	tableNDJSON: "application/x-ndjson",
+}
+
+// negotiateTableFormat picks the format of a list response: the format query parameter
+// (json, csv, ndjson) wins over the Accept header, JSON is the default.
+func negotiateTableFormat(r *http.Request) (tableFormat, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch f := tableFormat(format); f {
+		case tableJSON, tableCSV, tableNDJSON:
+			return f, nil
+		}
+		return "", fmt.Errorf("unsupported format %q, expected json, csv or ndjson", format)
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			return tableJSON, nil
+		case "text/csv":
+			return tableCSV, nil
+		case "application/x-ndjson":
+			return tableNDJSON, nil
+		}
+	}
+	return tableJSON, nil
+}
+
+// parseTimeRange reads the from/to filters of a list endpoint, see surferdata.ParseTimeBound
+func parseTimeRange(r *http.Request) (from, to time.Time, err error) {
+	query := r.URL.Query()
+	if from, err = surferdata.ParseTimeBound(query.Get("from"), false); err != nil {
+		return
+	}
+	if to, err = surferdata.ParseTimeBound(query.Get("to"), true); err != nil {
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		err = fmt.Errorf("from must be before to")
+	}
+	return
+}
+
+// tableFlushRows is how many rows are written between flushes. Exports that fit in
+// one batch stay buffered (and get an ETag, see withETag), longer ones stream.
+const tableFlushRows = 500
+
+// tableWriter streams rows as CSV (with a header line) or NDJSON. Rows are held back until a
+// batch is full, so an export that fails before its first flush can still answer with a problem.
+type tableWriter struct {
+	w      http.ResponseWriter
+	buf    bytes.Buffer // rows not sent to w yet
+	csv    *csv.Writer
+	json   *json.Encoder
+	rows   int
+	sent   bool // rows went out to the client, the status can't change anymore
+	failed bool
+}
+
+func newTableWriter(w http.ResponseWriter, format tableFormat, filename string, header []string) (*tableWriter, error) {
+	w.Header().Set("Content-Type", tableContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+"."+string(format)+`"`)
+
+	t := &tableWriter{w: w}
+	if format == tableNDJSON {
+		t.json = json.NewEncoder(&t.buf)
+		return t, nil
+	}
+	t.csv = csv.NewWriter(&t.buf)
+	return t, t.csv.Write(header)
+}
+
+// Row writes v, as record in CSV and as its JSON encoding in NDJSON
+func (t *tableWriter) Row(record []string, v any) error {
+	var err error
+	if t.json != nil {
+		err = t.json.Encode(v)
+	} else {
+		err = t.csv.Write(record)
+	}
+	if err != nil {
+		return err
+	}
+
+	t.rows++
+	if t.rows%tableFlushRows == 0 {
+		return t.flush()
+	}
+	return nil
+}
+
+// Close writes out what is still held back
+func (t *tableWriter) Close() error {
+	if t.failed {
+		return nil
+	}
+	if t.csv != nil {
+		t.csv.Flush()
+		if err := t.csv.Error(); err != nil {
+			return err
+		}
+	}
+	_, err := t.w.Write(t.buf.Bytes())
+	t.buf.Reset()
+	return err
+}
+
+// Fail discards the rows held back and answers with a problem if nothing was sent yet, so a
+// failed export is never served (or cached) as a complete one. Once rows went out the status
+// can't change anymore, the export just ends early.
+func (t *tableWriter) Fail(r *http.Request, detail string) {
+	t.failed = true
+	t.buf.Reset()
+	if !t.sent {
+		t.w.Header().Del("Content-Disposition")
+		api.Error(t.w, r, http.StatusInternalServerError, api.CodeInternal, detail)
+	}
+}
+
+func (t *tableWriter) flush() error {
+	if err := t.Close(); err != nil {
+		return err
+	}
+	t.sent = true
+	if f, ok := t.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+)
+
+func TestNegotiateTableFormat(t *testing.T) {
+	cases := []struct {
+		target, accept string
+		want           tableFormat
+	}{
+		{"/surfers", "", tableJSON},
+		{"/surfers", "*/*", tableJSON},
+		{"/surfers", "text/csv", tableCSV},
+		{"/surfers", "application/x-ndjson, application/json;q=0.5", tableNDJSON},
+		{"/surfers", "text/csv;q=0, application/json", tableJSON},
+		{"/surfers?format=ndjson", "text/csv", tableNDJSON},
+		{"/surfers?format=csv", "", tableCSV},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		r.Header.Set("Accept", tc.accept)
+		got, err := negotiateTableFormat(r)
+		if err != nil || got != tc.want {
+			t.Errorf("%s Accept=%q: got %q, %v, want %q", tc.target, tc.accept, got, err, tc.want)
+		}
+	}
+
+	if _, err := negotiateTableFormat(httptest.NewRequest(http.MethodGet, "/surfers?format=xml", nil)); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+func TestWaterHistoryAsCSV(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/conditions/water/history", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec := httptest.NewRecorder()
+	withHTTPCache(HandleWaterHistory(&conditions.MockWaterService{}))(rec, req)
+
+	t.Logf("%d %s\n%s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 2 || strings.Join(records[0], ",") != "timestamp,value" || records[1][1] != "143" {
+		t.Errorf("unexpected CSV: %v", records)
+	}
+	if rec.Header().Get("Vary") != "Accept" || rec.Header().Get("ETag") == "" {
+		t.Errorf("expected Vary: Accept and an ETag, got %v", rec.Header())
+	}
+}
+
+func TestLongTablesStreamWithoutETag(t *testing.T) {
+	handler := withETag(func(w http.ResponseWriter, r *http.Request) {
+		table, _ := newTableWriter(w, tableCSV, "numbers", []string{"n"})
+		for i := 0; i < 2*tableFlushRows; i++ {
+			table.Row([]string{strconv.Itoa(i)}, i)
+		}
+		table.Close()
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	lines := strings.Count(rec.Body.String(), "\n")
+	t.Logf("%d lines, flushed=%v, ETag=%q", lines, rec.Flushed, rec.Header().Get("ETag"))
+	if !rec.Flushed || rec.Header().Get("ETag") != "" {
+		t.Error("expected a long export to be flushed to the client instead of buffered for an ETag")
+	}
+	if lines != 2*tableFlushRows+1 {
+		t.Errorf("expected the header and %d rows, got %d lines", 2*tableFlushRows, lines)
+	}
+}
+
+func TestFailedBufferedExportIsAProblem(t *testing.T) {
+	handler := withETag(func(w http.ResponseWriter, r *http.Request) {
+		table, _ := newTableWriter(w, tableCSV, "numbers", []string{"n"})
+		for i := 0; i < 3; i++ {
+			table.Row([]string{strconv.Itoa(i)}, i)
+		}
+		table.Fail(r, "Failed to fetch numbers")
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/numbers", nil))
+	t.Logf("%d %s\n%s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("expected a problem, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if rec.Header().Get("ETag") != "" || rec.Header().Get("Content-Disposition") != "" {
+		t.Errorf("expected no ETag and no attachment, got %v", rec.Header())
+	}
+	if strings.Contains(rec.Body.String(), "\n0\n") || strings.HasPrefix(rec.Body.String(), "n\n") {
+		t.Error("expected the buffered rows to be discarded")
+	}
+}
@@ -0,0 +1,154 @@
+package surferdata
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+)
+
+const (
+	ReviewAccepted    = "accepted"
+	ReviewFlagged     = "flagged"     // counted, but shown in the review queue
+	ReviewQuarantined = "quarantined" // stored, but left out of aggregates and training until accepted
+	ReviewRejected    = "rejected"
+)
+
+// countedEntries is the SQL condition for entries that go into averages, predictions and training
+const countedEntries = `review_status IN ('accepted', 'flagged')`
+
+// How far back and how close reports have to be to be compared with a new one
+const (
+	slotLookback      = 28 * 24 * time.Hour
+	similarLevelRange = 10.0 // cm
+	similarTempRange  = 3.0  // °C water
+	similarAirRange   = 5.0  // °C air
+	similarHourRange  = 1    // hours either side, across midnight
+)
+
+// hoursApart is SQL for whether two hours of the day are at most within hours apart on the
+// clock, so that 23 and 0 are neighbors
+func hoursApart(a, b string, within int) string {
+	return fmt.Sprintf(`LEAST(ABS(%[1]s - %[2]s), 24 - ABS(%[1]s - %[2]s)) <= %[3]d`, a, b, within)
+}
+
+// AnomalyResult is the verdict on a new surfer report
+type AnomalyResult struct {
+	Score  float64 `json:"anomaly_score"`
+	Status string  `json:"review_status"`
+	Reason string  `json:"anomaly_reason,omitempty"`
+}
+
+// ScoreReport compares a count to recent reports for the same hour (slot) and to reports
+// under similar conditions, using a robust z-score (median and MAD) so earlier outliers
+// don't hide new ones. Distributions with fewer than MinSamples observations are ignored.
+func ScoreReport(count int, slot, similar []int, cfg config.AnomalyConfig) AnomalyResult {
+	result := AnomalyResult{Status: ReviewAccepted}
+
+	var reasons []string
+	for _, dist := range []struct {
+		name   string
+		counts []int
+	}{{"the same hour in the last 4 weeks", slot}, {"similar conditions", similar}} {
+		if len(dist.counts) < cfg.MinSamples {
+			continue
+		}
+		median, z := robustZ(count, dist.counts)
+		if math.Abs(z) > math.Abs(result.Score) {
+			result.Score = z
+		}
+		if math.Abs(z) >= cfg.FlagScore {
+			reasons = append(reasons, fmt.Sprintf("%d vs. median %.0f for %s (z=%.1f)", count, median, dist.name, z))
+		}
+	}
+
+	switch {
+	case count > cfg.MaxCount:
+		result.Status = ReviewQuarantined
+		reasons = append([]string{fmt.Sprintf("count %d is above the maximum of %d", count, cfg.MaxCount)}, reasons...)
+	case math.Abs(result.Score) >= cfg.QuarantineScore:
+		result.Status = ReviewQuarantined
+	case math.Abs(result.Score) >= cfg.FlagScore:
+		result.Status = ReviewFlagged
+	}
+	result.Reason = strings.Join(reasons, "; ")
+	return result
+}
+
+// robustZ is the modified z-score of Iglewicz and Hoaglin. Counts are small integers,
+// so the MAD is floored at 1 to keep a quiet slot (all zeros) from flagging every surfer.
+func robustZ(x int, values []int) (median, z float64) {
+	median = medianOf(values)
+	deviations := make([]int, len(values))
+	for i, v := range values {
+		deviations[i] = int(math.Abs(float64(v) - median))
+	}
+	mad := math.Max(medianOf(deviations), 1)
+	return median, 0.6745 * (float64(x) - median) / mad
+}
+
+func medianOf(values []int) float64 {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return float64(sorted[n/2])
+	}
+	return float64(sorted[n/2-1]+sorted[n/2]) / 2
+}
+
+// scoreEntry loads the comparison distributions for a new report and scores it. Reports under
+// similar conditions are only compared once water and air temperature and level are known.
+func (s *Service) scoreEntry(ctx context.Context, count int, when time.Time, snap ConditionSnapshot) (AnomalyResult, error) {
+	hour, spot := when.In(conditions.SpotLocation()).Hour(), conditions.SpotLocation().String()
+	slot, err := s.countsWhere(ctx,
+		`EXTRACT(HOUR FROM timestamp AT TIME ZONE $4) = $1 AND timestamp >= $2 AND timestamp < $3`,
+		hour, when.Add(-slotLookback), when, spot)
+	if err != nil {
+		return AnomalyResult{}, err
+	}
+
+	waterTemp, knownWater := snap.known(conditions.HistoryWaterTemperature)
+	airTemp, knownAir := snap.known(conditions.HistoryAirTemperature)
+	waterLevel, knownLevel := snap.known(conditions.HistoryWaterLevel)
+	var similar []int
+	if knownWater && knownAir && knownLevel {
+		similar, err = s.countsWhere(ctx,
+			hoursApart(`EXTRACT(HOUR FROM timestamp AT TIME ZONE $9)`, `$1`, similarHourRange)+`
+			 AND timestamp < $8
+			 AND ABS(water_level - $2) <= $3
+			 AND ABS(water_temperature - $4) <= $5
+			 AND ABS(air_temperature - $6) <= $7
+			 AND conditions_status->>'water_temperature' IN ('measured', 'interpolated')
+			 AND conditions_status->>'air_temperature' IN ('measured', 'interpolated')`,
+			hour, waterLevel, similarLevelRange, waterTemp, similarTempRange, airTemp, similarAirRange, when, spot)
+		if err != nil {
+			return AnomalyResult{}, err
+		}
+	}
+
+	return ScoreReport(count, slot, similar, config.Anomaly), nil
+}
+
+func (s *Service) countsWhere(ctx context.Context, where string, args ...any) ([]int, error) {
+	rows, err := s.DB.Query(ctx, `SELECT count FROM surfer_entries WHERE `+countedEntries+` AND `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []int
+	for rows.Next() {
+		var c int
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
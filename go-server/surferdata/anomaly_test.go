@@ -0,0 +1,76 @@
+package surferdata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+)
+
+var testAnomalyConfig = config.AnomalyConfig{FlagScore: 3.5, QuarantineScore: 6, MaxCount: 80, MinSamples: 5}
+
+func TestScoreReportTypoIsQuarantined(t *testing.T) {
+	slot := []int{8, 10, 12, 9, 11, 10, 7}
+	similar := []int{9, 10, 13, 8, 12}
+
+	result := ScoreReport(50, slot, similar, testAnomalyConfig)
+	t.Logf("50 surfers → %+v", result)
+	if result.Status != ReviewQuarantined || result.Reason == "" {
+		t.Errorf("expected 50 surfers in a slot of ~10 to be quarantined, got %+v", result)
+	}
+
+	typo := ScoreReport(500, nil, nil, testAnomalyConfig)
+	if typo.Status != ReviewQuarantined {
+		t.Errorf("expected counts above the maximum to be quarantined without history, got %+v", typo)
+	}
+}
+
+func TestScoreReportNormalAndSuspicious(t *testing.T) {
+	slot := []int{8, 10, 12, 9, 11, 10, 7}
+
+	if result := ScoreReport(13, slot, nil, testAnomalyConfig); result.Status != ReviewAccepted {
+		t.Errorf("expected 13 surfers to be accepted, got %+v", result)
+	}
+	if result := ScoreReport(18, slot, nil, testAnomalyConfig); result.Status != ReviewFlagged {
+		t.Errorf("expected 18 surfers to be flagged, got %+v", result)
+	}
+}
+
+func TestScoreReportNeedsEnoughSamples(t *testing.T) {
+	result := ScoreReport(40, []int{2, 3}, []int{1}, testAnomalyConfig)
+	if result.Status != ReviewAccepted || result.Score != 0 {
+		t.Errorf("expected no verdict from too little history, got %+v", result)
+	}
+
+	// A quiet slot (all zeros) must not flag a handful of surfers
+	quiet := ScoreReport(2, []int{0, 0, 0, 0, 0, 0}, nil, testAnomalyConfig)
+	if quiet.Status != ReviewAccepted {
+		t.Errorf("expected 2 surfers in a quiet slot to be accepted, got %+v", quiet)
+	}
+}
+
+func TestHoursApartWrapsAroundMidnight(t *testing.T) {
+	service := setupTestService(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := service.DB.Ping(ctx); err != nil {
+		t.Skipf("test database unavailable: %v", err)
+	}
+
+	cases := []struct {
+		a, b int
+		want bool
+	}{{23, 0, true}, {0, 23, true}, {22, 0, false}, {12, 13, true}, {12, 14, false}, {5, 5, true}}
+	for _, tc := range cases {
+		var near bool
+		err := service.DB.QueryRow(context.Background(),
+			`SELECT `+hoursApart(`$1::int`, `$2::int`, similarHourRange), tc.a, tc.b).Scan(&near)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if near != tc.want {
+			t.Errorf("%d and %d: got %v, want %v", tc.a, tc.b, near, tc.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package surferdata
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+)
+
+// BackfillOptions selects the entries BackfillConditions looks at; zero values mean no limit
+type BackfillOptions struct {
+	From   time.Time
+	To     time.Time // exclusive
+	Limit  int
+	DryRun bool
+}
+
+// BackfillReport counts placeholders per field: replaced by measured values, or left
+// because no history covers the entry's time (yet)
+type BackfillReport struct {
+	DryRun      bool                            `json:"dry_run"`
+	Entries     int                             `json:"entries"` // entries with placeholders
+	Updated     int                             `json:"updated"` // entries with at least one value backfilled
+	Backfilled  map[conditions.HistoryField]int `json:"backfilled"`
+	Unavailable map[conditions.HistoryField]int `json:"unavailable"`
+}
+
+// placeholderEntry is a stored entry with the conditions it was stored with
+type placeholderEntry struct {
+	ID               int
+	Timestamp        time.Time
+	WaterTemperature float64
+	AirTemperature   float64
+	WeatherCondition int
+	WaterLevel       *float64
+	WaterFlow        *float64
+	Status           map[conditions.HistoryField]string // see ConditionSnapshot
+}
+
+// placeholders lists the fields of e that hold placeholders instead of measurements: the
+// conditions that are missing (0 or -1 from a failed fetch, 18 °C from V5, no level or flow
+// before those were recorded).
+func (e placeholderEntry) placeholders() []conditions.HistoryField {
+	var fields []conditions.HistoryField
+	for _, field := range conditionFields {
+		if e.Status[field] == ConditionMissing {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// value returns what is stored in field; nil for NULL
+func (e placeholderEntry) value(field conditions.HistoryField) *float64 {
+	var v float64
+	switch field {
+	case conditions.HistoryWaterTemperature:
+		v = e.WaterTemperature
+	case conditions.HistoryAirTemperature:
+		v = e.AirTemperature
+	case conditions.HistoryWeatherCondition:
+		v = float64(e.WeatherCondition)
+	case conditions.HistoryWaterLevel:
+		return e.WaterLevel
+	case conditions.HistoryWaterFlow:
+		return e.WaterFlow
+	}
+	return &v
+}
+
+// backfillValue is a measured value that replaces a placeholder
+type backfillValue struct {
+	field conditions.HistoryField
+	old   *float64
+	conditions.Measurement
+	conditions.Provenance
+}
+
+// historyKey is a series as fetched: one field for one local month
+type historyKey struct {
+	field conditions.HistoryField
+	month time.Time
+}
+
+// BackfillConditions replaces the missing conditions of stored entries with the values measured
+// at their time, looked up in history a month at a time. Each replaced value is marked measured
+// and recorded with its source in surfer_entry_backfills. Rejected entries are left alone. A dry run
+// looks everything up but writes nothing.
+func (s *Service) BackfillConditions(ctx context.Context, history conditions.HistoryProvider, opts BackfillOptions) (BackfillReport, error) {
+	report := BackfillReport{
+		DryRun:      opts.DryRun,
+		Backfilled:  map[conditions.HistoryField]int{},
+		Unavailable: map[conditions.HistoryField]int{},
+	}
+
+	entries, err := s.placeholderEntries(ctx, opts)
+	if err != nil {
+		return report, err
+	}
+	report.Entries = len(entries)
+
+	// A failed fetch is remembered as nil, so a month isn't retried for every entry
+	fetched := map[historyKey]*conditions.Series{}
+	seriesFor := func(field conditions.HistoryField, at time.Time) *conditions.Series {
+		month := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, at.Location())
+		key := historyKey{field, month}
+		if series, ok := fetched[key]; ok {
+			return series
+		}
+		series, err := history.History(ctx, field, month, month.AddDate(0, 1, 0))
+		if err != nil {
+			slog.WarnContext(ctx, "could not fetch history", "field", field, "month", month.Format("2006-01"), "err", err)
+		}
+		fetched[key] = series
+		return series
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		var values []backfillValue
+		for _, field := range entry.placeholders() {
+			series := seriesFor(field, entry.Timestamp)
+			if series == nil {
+				report.Unavailable[field]++
+				continue
+			}
+			m, ok := series.At(entry.Timestamp)
+			if !ok {
+				report.Unavailable[field]++
+				continue
+			}
+			if field == conditions.HistoryWeatherCondition {
+				m.Value = math.Round(m.Value)
+			}
+			values = append(values, backfillValue{field: field, old: entry.value(field), Measurement: m, Provenance: series.Provenance})
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := pgx.BeginFunc(ctx, s.DB, func(tx pgx.Tx) error {
+				return backfillEntryTx(ctx, tx, entry.ID, values)
+			}); err != nil {
+				return report, fmt.Errorf("backfilling entry %d: %w", entry.ID, err)
+			}
+		}
+		report.Updated++
+		for _, v := range values {
+			report.Backfilled[v.field]++
+		}
+	}
+	return report, nil
+}
+
+// placeholderEntries loads the entries that have at least one placeholder, oldest first
+func (s *Service) placeholderEntries(ctx context.Context, opts BackfillOptions) ([]placeholderEntry, error) {
+	var from, to *time.Time
+	if !opts.From.IsZero() {
+		from = &opts.From
+	}
+	if !opts.To.IsZero() {
+		to = &opts.To
+	}
+
+	rows, err := s.DB.Query(ctx,
+		`SELECT id, timestamp, water_temperature, air_temperature, weather_condition, water_level, water_flow, conditions_status
+		 FROM surfer_entries
+		 WHERE review_status <> 'rejected'
+		   AND conditions_enriched_at IS NOT NULL -- pending entries are still being looked up
+		   AND EXISTS (SELECT 1 FROM jsonb_each_text(conditions_status) s WHERE s.value = 'missing')
+		   AND ($1::timestamptz IS NULL OR timestamp >= $1)
+		   AND ($2::timestamptz IS NULL OR timestamp < $2)
+		 ORDER BY timestamp, id
+		 LIMIT NULLIF($3, 0)`,
+		from, to, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []placeholderEntry
+	for rows.Next() {
+		var e placeholderEntry
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.WaterTemperature, &e.AirTemperature, &e.WeatherCondition,
+			&e.WaterLevel, &e.WaterFlow, &e.Status); err != nil {
+			return nil, err
+		}
+		e.Timestamp = e.Timestamp.In(conditions.SpotLocation())
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func backfillEntryTx(ctx context.Context, tx pgx.Tx, entryID int, values []backfillValue) error {
+	for _, v := range values {
+		var value any = v.Value
+		if v.field == conditions.HistoryWeatherCondition {
+			value = int(v.Value)
+		}
+		// v.field is a conditions.HistoryField constant, named like its column
+		if _, err := tx.Exec(ctx,
+			fmt.Sprintf(`UPDATE surfer_entries SET %s = $1 WHERE id = $2`, v.field),
+			value, entryID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE surfer_entries SET conditions_status = conditions_status || jsonb_build_object($1::text, $2::text) WHERE id = $3`,
+			v.field, ConditionMeasured, entryID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO surfer_entry_backfills (entry_id, field, old_value, new_value, source, station_id, observed_at)
+			 VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)`,
+			entryID, v.field, v.old, v.Value, v.Source, v.StationID, v.Time); err != nil {
+			return err
+		}
+	}
+	return nil
+}
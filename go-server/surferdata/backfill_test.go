@@ -0,0 +1,79 @@
+package surferdata
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+)
+
+func TestPlaceholders(t *testing.T) {
+	measured := func(missing ...conditions.HistoryField) map[conditions.HistoryField]string {
+		status := map[conditions.HistoryField]string{}
+		for _, field := range conditionFields {
+			status[field] = ConditionMeasured
+		}
+		for _, field := range missing {
+			status[field] = ConditionMissing
+		}
+		return status
+	}
+	cases := []struct {
+		name  string
+		entry placeholderEntry
+		want  []conditions.HistoryField
+	}{
+		{"measured", placeholderEntry{Status: measured()}, nil},
+		{"failed fetches", placeholderEntry{Status: measured(conditionFields...)}, conditionFields},
+		{"V5 default", placeholderEntry{Status: measured(conditions.HistoryAirTemperature, conditions.HistoryWeatherCondition)},
+			[]conditions.HistoryField{conditions.HistoryAirTemperature, conditions.HistoryWeatherCondition}},
+		{"imported without weather code", placeholderEntry{Status: measured(conditions.HistoryWeatherCondition)},
+			[]conditions.HistoryField{conditions.HistoryWeatherCondition}},
+	}
+	for _, tc := range cases {
+		if got := tc.entry.placeholders(); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// fakeHistory has a constant value for every field and remembers what was asked for
+type fakeHistory struct {
+	requests []conditions.HistoryField
+}
+
+func (f *fakeHistory) History(ctx context.Context, field conditions.HistoryField, from, to time.Time) (*conditions.Series, error) {
+	f.requests = append(f.requests, field)
+	return &conditions.Series{
+		Values:     []conditions.Measurement{{Time: from, Value: 7}},
+		Interval:   to.Sub(from),
+		Provenance: conditions.Provenance{Source: "fake"},
+	}, nil
+}
+
+func TestBackfillConditionsDryRun(t *testing.T) {
+	service := setupTestService(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := service.DB.Ping(ctx); err != nil {
+		t.Skipf("test database unavailable: %v", err)
+	}
+
+	history := &fakeHistory{}
+	report, err := service.BackfillConditions(context.Background(), history, BackfillOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("BackfillConditions failed: %v", err)
+	}
+	t.Logf("%+v, %d history requests", report, len(history.requests))
+	if report.Entries > 0 && report.Updated != report.Entries {
+		t.Errorf("expected every entry with placeholders to be backfilled from a complete history, got %+v", report)
+	}
+
+	// Nothing was written, so the same entries still have placeholders
+	again, err := service.BackfillConditions(context.Background(), history, BackfillOptions{DryRun: true})
+	if err != nil || again.Entries != report.Entries {
+		t.Errorf("expected a dry run to write nothing, got %+v, %v", again, err)
+	}
+}
@@ -0,0 +1,418 @@
+package surferdata
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+)
+
+// How each condition of an entry was filled, see ConditionSnapshot
+const (
+	ConditionMeasured     = "measured"     // an upstream reading covering the entry's time
+	ConditionInterpolated = "interpolated" // derived from readings stored before and after the entry's time
+	ConditionMissing      = "missing"      // nothing found in time; stored as 0, -1 or NULL as before
+)
+
+// conditionFields are the conditions snapshotted for every entry, named like their columns
+var conditionFields = []conditions.HistoryField{
+	conditions.HistoryWaterTemperature, conditions.HistoryAirTemperature, conditions.HistoryWeatherCondition,
+	conditions.HistoryWaterLevel, conditions.HistoryWaterFlow,
+}
+
+const (
+	enrichInterval  = time.Minute
+	enrichBatchSize = 100
+	neighborWindow  = time.Hour      // how close stored readings have to be to interpolate from them
+	enrichGiveUp    = 48 * time.Hour // GKD publishes the daily mean the next day; after that a condition is missing
+)
+
+// ConditionSnapshot is what is known about the conditions of an entry. Fields without
+// a status are still pending.
+type ConditionSnapshot struct {
+	Values map[conditions.HistoryField]float64
+	Status map[conditions.HistoryField]string
+}
+
+func newConditionSnapshot() ConditionSnapshot {
+	return ConditionSnapshot{
+		Values: map[conditions.HistoryField]float64{},
+		Status: map[conditions.HistoryField]string{},
+	}
+}
+
+func (c ConditionSnapshot) set(field conditions.HistoryField, value float64, status string) {
+	c.Values[field] = value
+	c.Status[field] = status
+}
+
+// known returns the value of field if it was measured or interpolated
+func (c ConditionSnapshot) known(field conditions.HistoryField) (float64, bool) {
+	switch c.Status[field] {
+	case ConditionMeasured, ConditionInterpolated:
+		return c.Values[field], true
+	}
+	return 0, false
+}
+
+// pending lists the fields that have no status yet
+func (c ConditionSnapshot) pending() []conditions.HistoryField {
+	var fields []conditions.HistoryField
+	for _, field := range conditionFields {
+		if _, ok := c.Status[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// column returns what is stored for field: the value, or the placeholder the column
+// always had for an unknown condition
+func (c ConditionSnapshot) column(field conditions.HistoryField) any {
+	value, ok := c.known(field)
+	switch {
+	case field == conditions.HistoryWeatherCondition && ok:
+		return int(value)
+	case field == conditions.HistoryWeatherCondition:
+		return -1
+	case ok:
+		return value
+	case field == conditions.HistoryWaterLevel || field == conditions.HistoryWaterFlow:
+		return nil
+	default:
+		return 0.0
+	}
+}
+
+// reading is an upstream value of one condition
+type reading struct {
+	field conditions.HistoryField
+	value float64
+	conditions.Provenance
+}
+
+// timedValue is a value of one condition at a point in time
+type timedValue struct {
+	at    time.Time
+	value float64
+}
+
+// snapshotConditions fills the pending fields of snap for an entry at t: from the upstream
+// reading closest to t if one covers it (measured), else from the stored readings around t,
+// HND levels and other entries (interpolated). What is still unknown once the entry is older
+// than enrichGiveUp is missing.
+func snapshotConditions(snap ConditionSnapshot, t, now time.Time, readings []reading, levels []timedValue, neighbors []storedConditions) {
+	for _, field := range snap.pending() {
+		var best *reading
+		for i, r := range readings {
+			if r.field != field || !r.Covers(t) {
+				continue
+			}
+			if best == nil || absDuration(r.ObservedAt.Sub(t)) < absDuration(best.ObservedAt.Sub(t)) {
+				best = &readings[i]
+			}
+		}
+		if best != nil {
+			snap.set(field, best.value, ConditionMeasured)
+		}
+	}
+
+	if _, ok := snap.Status[conditions.HistoryWaterLevel]; !ok {
+		if value, ok := interpolateAt(t, levels, true); ok {
+			snap.set(conditions.HistoryWaterLevel, value, ConditionInterpolated)
+		}
+	}
+
+	for _, field := range snap.pending() {
+		var points []timedValue
+		for _, n := range neighbors {
+			if n.Status[field] == ConditionMeasured {
+				points = append(points, timedValue{n.Timestamp, n.Values[field]})
+			}
+		}
+		// A weather code can't be averaged, the closest one is taken
+		if value, ok := interpolateAt(t, points, field != conditions.HistoryWeatherCondition); ok {
+			snap.set(field, value, ConditionInterpolated)
+		}
+	}
+
+	if now.Sub(t) > enrichGiveUp {
+		for _, field := range snap.pending() {
+			snap.set(field, 0, ConditionMissing)
+		}
+	}
+}
+
+// interpolateAt estimates the value at t from the closest points before and after it within
+// neighborWindow: linearly between both, or the closer one if linear is false or there is only one
+func interpolateAt(t time.Time, points []timedValue, linear bool) (float64, bool) {
+	var before, after *timedValue
+	for i, p := range points {
+		d := p.at.Sub(t)
+		if absDuration(d) > neighborWindow {
+			continue
+		}
+		if d <= 0 && (before == nil || p.at.After(before.at)) {
+			before = &points[i]
+		}
+		if d >= 0 && (after == nil || p.at.Before(after.at)) {
+			after = &points[i]
+		}
+	}
+
+	switch {
+	case before == nil && after == nil:
+		return 0, false
+	case before == nil:
+		return after.value, true
+	case after == nil || !after.at.After(before.at):
+		return before.value, true
+	case !linear:
+		if t.Sub(before.at) <= after.at.Sub(t) {
+			return before.value, true
+		}
+		return after.value, true
+	default:
+		share := float64(t.Sub(before.at)) / float64(after.at.Sub(before.at))
+		return math.Round((before.value+share*(after.value-before.value))*100) / 100, true
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// storedConditions is an entry as stored, with the status of its conditions
+type storedConditions struct {
+	ID           int
+	Timestamp    time.Time
+	Count        int
+	ReviewStatus string
+	Reviewed     bool
+	ConditionSnapshot
+}
+
+// RequestEnrichment asks EnrichConditions to look at pending entries now instead of on its next tick
+func (s *Service) RequestEnrichment() {
+	select {
+	case s.enrichRequests <- struct{}{}:
+	default:
+	}
+}
+
+// EnrichConditions snapshots the conditions of entries stored without them: right after
+// AddEntry asks for it, and every minute for what is still pending. Runs until ctx is cancelled.
+func (s *Service) EnrichConditions(ctx context.Context) {
+	ticker := time.NewTicker(enrichInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.EnrichPending(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("could not enrich surfer entries", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.enrichRequests:
+		}
+	}
+}
+
+// EnrichPending fills in what is known now about the conditions of pending entries, oldest
+// first, and rescores the ones that are complete. It returns how many it completed.
+func (s *Service) EnrichPending(ctx context.Context) (int, error) {
+	entries, err := s.pendingEntries(ctx)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	readings, levels := s.fetchReadings(ctx)
+	now := time.Now()
+	completed := 0
+	for _, entry := range entries {
+		neighbors, err := s.neighborConditions(ctx, entry)
+		if err != nil {
+			return completed, err
+		}
+		snapshotConditions(entry.ConditionSnapshot, entry.Timestamp, now, readings, levels, neighbors)
+
+		complete := len(entry.pending()) == 0
+		if _, err := s.DB.Exec(ctx,
+			`UPDATE surfer_entries
+			 SET water_temperature = $2, air_temperature = $3, weather_condition = $4, water_level = $5, water_flow = $6,
+			     conditions_status = $7, conditions_enriched_at = CASE WHEN $8 THEN now() END
+			 WHERE id = $1`,
+			entry.ID,
+			entry.column(conditions.HistoryWaterTemperature), entry.column(conditions.HistoryAirTemperature),
+			entry.column(conditions.HistoryWeatherCondition), entry.column(conditions.HistoryWaterLevel),
+			entry.column(conditions.HistoryWaterFlow), entry.ConditionSnapshot.Status, complete); err != nil {
+			return completed, err
+		}
+		if !complete {
+			continue
+		}
+		completed++
+		if err := s.rescoreEntry(ctx, entry); err != nil {
+			slog.WarnContext(ctx, "could not rescore surfer report", "id", entry.ID, "err", err)
+		}
+	}
+	return completed, nil
+}
+
+// fetchReadings gets the latest upstream values through the (cached) providers, plus the past
+// hours of today's weather forecast and the HND level table. Failed fetches are logged and left out.
+func (s *Service) fetchReadings(ctx context.Context) ([]reading, []timedValue) {
+	var readings []reading
+	var levels []timedValue
+
+	if s.AirService != nil {
+		if forecast, err := s.AirService.GetForecast(ctx); err != nil {
+			slog.WarnContext(ctx, "could not fetch air weather", "err", err)
+		} else {
+			current := forecast.CurrentWeather()
+			readings = append(readings,
+				reading{conditions.HistoryAirTemperature, current.Temp, current.Provenance},
+				reading{conditions.HistoryWeatherCondition, float64(current.Condition), current.Provenance})
+			for _, h := range forecast.Hourly {
+				if h.Time.After(current.ObservedAt) {
+					break // forecasts, not readings
+				}
+				p := forecast.Provenance
+				p.ObservedAt = h.Time
+				readings = append(readings,
+					reading{conditions.HistoryAirTemperature, h.Temperature, p},
+					reading{conditions.HistoryWeatherCondition, float64(h.WeatherCode), p})
+			}
+		}
+	}
+
+	if s.WaterService != nil {
+		if temperature, err := s.WaterService.GetLatestWaterTemperature(ctx); err != nil {
+			slog.WarnContext(ctx, "could not fetch water temperature", "err", err)
+		} else {
+			readings = append(readings, reading{conditions.HistoryWaterTemperature, temperature.Value, temperature.Provenance})
+		}
+
+		if result, err := s.WaterService.GetLatestWaterLevelAndFlow(ctx); err != nil {
+			slog.WarnContext(ctx, "could not fetch water level and flow", "err", err)
+		} else {
+			readings = append(readings,
+				reading{conditions.HistoryWaterLevel, result.Level, result.Provenance},
+				reading{conditions.HistoryWaterFlow, result.Flow, result.Provenance})
+		}
+
+		if history, err := s.WaterService.GetHistoricalWaterLevels(ctx); err != nil {
+			slog.WarnContext(ctx, "could not fetch water level history", "err", err)
+		} else if history != nil {
+			for _, level := range history.Levels {
+				levels = append(levels, timedValue{level.Time, level.Value})
+			}
+		}
+	}
+	return readings, levels
+}
+
+// pendingEntries loads the entries whose conditions are still being looked up, oldest first
+func (s *Service) pendingEntries(ctx context.Context) ([]storedConditions, error) {
+	rows, err := s.DB.Query(ctx,
+		`SELECT id, timestamp, count, review_status, reviewed_at IS NOT NULL,
+		        water_temperature, air_temperature, weather_condition, water_level, water_flow, conditions_status
+		 FROM surfer_entries
+		 WHERE conditions_enriched_at IS NULL AND review_status <> 'rejected'
+		 ORDER BY timestamp, id
+		 LIMIT $1`, enrichBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	return scanStoredConditions(rows)
+}
+
+// neighborConditions loads the entries stored within neighborWindow of entry
+func (s *Service) neighborConditions(ctx context.Context, entry storedConditions) ([]storedConditions, error) {
+	rows, err := s.DB.Query(ctx,
+		`SELECT id, timestamp, count, review_status, reviewed_at IS NOT NULL,
+		        water_temperature, air_temperature, weather_condition, water_level, water_flow, conditions_status
+		 FROM surfer_entries
+		 WHERE id <> $1 AND review_status <> 'rejected' AND conditions_status IS NOT NULL
+		   AND timestamp BETWEEN $2 AND $3`,
+		entry.ID, entry.Timestamp.Add(-neighborWindow), entry.Timestamp.Add(neighborWindow))
+	if err != nil {
+		return nil, err
+	}
+	return scanStoredConditions(rows)
+}
+
+func scanStoredConditions(rows pgx.Rows) ([]storedConditions, error) {
+	defer rows.Close()
+
+	var entries []storedConditions
+	for rows.Next() {
+		var (
+			e                     storedConditions
+			waterTemp, airTemp    float64
+			weather               int
+			waterLevel, waterFlow *float64
+			status                map[conditions.HistoryField]string
+		)
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Count, &e.ReviewStatus, &e.Reviewed,
+			&waterTemp, &airTemp, &weather, &waterLevel, &waterFlow, &status); err != nil {
+			return nil, err
+		}
+		e.Timestamp = e.Timestamp.In(conditions.SpotLocation())
+		e.ConditionSnapshot = storedSnapshot(waterTemp, airTemp, weather, waterLevel, waterFlow, status)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// storedSnapshot pairs the condition columns of an entry with its conditions_status
+func storedSnapshot(waterTemp, airTemp float64, weather int, waterLevel, waterFlow *float64, status map[conditions.HistoryField]string) ConditionSnapshot {
+	snap := newConditionSnapshot()
+	stored := map[conditions.HistoryField]float64{
+		conditions.HistoryWaterTemperature: waterTemp,
+		conditions.HistoryAirTemperature:   airTemp,
+		conditions.HistoryWeatherCondition: float64(weather),
+		conditions.HistoryWaterLevel:       safeFloat(waterLevel),
+		conditions.HistoryWaterFlow:        safeFloat(waterFlow),
+	}
+	for field, s := range status {
+		snap.set(field, stored[field], s)
+	}
+	return snap
+}
+
+// reviewSeverity orders the review statuses a score can lead to
+var reviewSeverity = map[string]int{ReviewAccepted: 0, ReviewFlagged: 1, ReviewQuarantined: 2}
+
+// rescoreEntry scores an entry again once its conditions are known. It only ever raises the
+// review status, and leaves entries alone that were reviewed already.
+func (s *Service) rescoreEntry(ctx context.Context, entry storedConditions) error {
+	if entry.Reviewed {
+		return nil
+	}
+	result, err := s.scoreEntry(ctx, entry.Count, entry.Timestamp, entry.ConditionSnapshot)
+	if err != nil {
+		return err
+	}
+	if reviewSeverity[result.Status] <= reviewSeverity[entry.ReviewStatus] {
+		return nil
+	}
+
+	tag, err := s.DB.Exec(ctx,
+		`UPDATE surfer_entries SET review_status = $2, anomaly_score = $3, anomaly_reason = NULLIF($4, '')
+		 WHERE id = $1 AND review_status = $5 AND reviewed_at IS NULL`,
+		entry.ID, result.Status, result.Score, result.Reason, entry.ReviewStatus)
+	if err == nil && tag.RowsAffected() > 0 {
+		slog.InfoContext(ctx, "surfer report held for review once its conditions were known",
+			"id", entry.ID, "count", entry.Count, "review_status", result.Status, "anomaly_score", result.Score, "reason", result.Reason)
+	}
+	return err
+}
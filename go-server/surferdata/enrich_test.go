@@ -0,0 +1,129 @@
+package surferdata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+)
+
+// downWaterService fails like PegelAlarm and GKD do when they are down
+type downWaterService struct{}
+
+func (downWaterService) GetLatestWaterTemperature(ctx context.Context) (*conditions.WaterTemperature, error) {
+	return nil, errors.New("gkd down")
+}
+
+func (downWaterService) GetLatestWaterLevelAndFlow(ctx context.Context) (*conditions.WaterLevelAndFlow, error) {
+	return nil, errors.New("pegelalarm down")
+}
+
+func (downWaterService) GetHistoricalWaterLevels(ctx context.Context) (*conditions.WaterLevelHistory, error) {
+	return nil, errors.New("hnd down")
+}
+
+func TestFetchReadingsWithUpstreamsDown(t *testing.T) {
+	service := &Service{WaterService: downWaterService{}, AirService: &MockAirService{}}
+	readings, levels := service.fetchReadings(context.Background())
+	t.Logf("%+v %v", readings, levels)
+
+	for _, r := range readings {
+		if r.field != conditions.HistoryAirTemperature && r.field != conditions.HistoryWeatherCondition {
+			t.Errorf("expected no water readings while the water upstreams are down, got %+v", r)
+		}
+	}
+	if len(levels) != 0 {
+		t.Errorf("expected no levels, got %v", levels)
+	}
+}
+
+func TestSnapshotConditions(t *testing.T) {
+	loc := conditions.SpotLocation()
+	at := time.Date(2025, 6, 21, 14, 10, 0, 0, loc)
+	now := at.Add(5 * time.Minute)
+	observed := func(source string, t time.Time) conditions.Provenance {
+		return conditions.Provenance{Source: source, ObservedAt: t}
+	}
+
+	readings := []reading{
+		{conditions.HistoryAirTemperature, 24.1, observed(conditions.SourceOpenMeteo, at.Add(-10*time.Minute))},
+		{conditions.HistoryAirTemperature, 23.0, observed(conditions.SourceOpenMeteo, at.Add(-70*time.Minute))},
+		{conditions.HistoryWeatherCondition, 2, observed(conditions.SourceOpenMeteo, at.Add(-10*time.Minute))},
+		// Too old for the entry
+		{conditions.HistoryWaterLevel, 150, observed(conditions.SourcePegelAlarm, at.Add(-3*time.Hour))},
+	}
+	levels := []timedValue{{at.Add(-10 * time.Minute), 140}, {at.Add(5 * time.Minute), 146}}
+	neighbors := []storedConditions{
+		{Timestamp: at.Add(-20 * time.Minute), ConditionSnapshot: ConditionSnapshot{
+			Values: map[conditions.HistoryField]float64{conditions.HistoryWaterFlow: 20},
+			Status: map[conditions.HistoryField]string{conditions.HistoryWaterFlow: ConditionMeasured},
+		}},
+		{Timestamp: at.Add(20 * time.Minute), ConditionSnapshot: ConditionSnapshot{
+			Values: map[conditions.HistoryField]float64{conditions.HistoryWaterFlow: 22, conditions.HistoryWaterTemperature: 0},
+			Status: map[conditions.HistoryField]string{conditions.HistoryWaterFlow: ConditionMeasured, conditions.HistoryWaterTemperature: ConditionMissing},
+		}},
+	}
+
+	snap := newConditionSnapshot()
+	snapshotConditions(snap, at, now, readings, levels, neighbors)
+	t.Logf("%+v", snap)
+
+	want := map[conditions.HistoryField]struct {
+		value  float64
+		status string
+	}{
+		conditions.HistoryAirTemperature:   {24.1, ConditionMeasured},
+		conditions.HistoryWeatherCondition: {2, ConditionMeasured},
+		conditions.HistoryWaterLevel:       {144, ConditionInterpolated},
+		conditions.HistoryWaterFlow:        {21, ConditionInterpolated},
+	}
+	for field, w := range want {
+		if snap.Status[field] != w.status || snap.Values[field] != w.value {
+			t.Errorf("%s: got %.2f %s, want %.2f %s", field, snap.Values[field], snap.Status[field], w.value, w.status)
+		}
+	}
+	// A missing neighbor is no reading; the water temperature is looked up again later
+	if pending := snap.pending(); len(pending) != 1 || pending[0] != conditions.HistoryWaterTemperature {
+		t.Errorf("expected only the water temperature to be pending, got %v", pending)
+	}
+
+	snapshotConditions(snap, at, at.Add(enrichGiveUp+time.Hour), nil, nil, nil)
+	if snap.Status[conditions.HistoryWaterTemperature] != ConditionMissing || snap.column(conditions.HistoryWaterTemperature) != 0.0 {
+		t.Errorf("expected the water temperature to be missing after %s, got %+v", enrichGiveUp, snap)
+	}
+}
+
+func TestInterpolateAt(t *testing.T) {
+	at := time.Date(2025, 6, 21, 14, 0, 0, 0, time.UTC)
+	points := []timedValue{{at.Add(-30 * time.Minute), 3}, {at.Add(15 * time.Minute), 61}, {at.Add(-2 * time.Hour), 0}}
+
+	if v, ok := interpolateAt(at, points, false); !ok || v != 61 {
+		t.Errorf("expected the closest weather code, got %v, %v", v, ok)
+	}
+	if v, ok := interpolateAt(at, points[:1], true); !ok || v != 3 {
+		t.Errorf("expected the only point in the window, got %v, %v", v, ok)
+	}
+	if _, ok := interpolateAt(at, points[2:], true); ok {
+		t.Error("expected points outside the window to be ignored")
+	}
+}
+
+func TestConditionSnapshotColumns(t *testing.T) {
+	snap := newConditionSnapshot()
+	snap.set(conditions.HistoryWeatherCondition, 0, ConditionMissing)
+	snap.set(conditions.HistoryWaterFlow, 21.5, ConditionInterpolated)
+
+	cases := map[conditions.HistoryField]any{
+		conditions.HistoryWaterTemperature: 0.0, // pending
+		conditions.HistoryWeatherCondition: -1,
+		conditions.HistoryWaterLevel:       nil,
+		conditions.HistoryWaterFlow:        21.5,
+	}
+	for field, want := range cases {
+		if got := snap.column(field); got != want {
+			t.Errorf("%s: got %#v, want %#v", field, got, want)
+		}
+	}
+}
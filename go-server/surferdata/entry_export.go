@@ -0,0 +1,25 @@
+package surferdata
+
+import (
+	"strconv"
+	"time"
+)
+
+// EntryCSVHeader is the column order of surfer entry exports, named like the JSON fields
+var EntryCSVHeader = []string{
+	"timestamp", "count", "water_temperature", "air_temperature", "weather_condition", "water_level", "water_flow",
+}
+
+// CSVRecord formats the entry in the column order of EntryCSVHeader
+func (e SurferEntryResponse) CSVRecord() []string {
+	float := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	return []string{
+		e.Timestamp.Format(time.RFC3339),
+		strconv.Itoa(e.Count),
+		float(e.WaterTemperature),
+		float(e.AirTemperature),
+		strconv.Itoa(e.WeatherCondition),
+		float(e.WaterLevel),
+		float(e.WaterFlow),
+	}
+}
@@ -7,9 +7,16 @@ func safeFloat(f *float64) float64 {
 	return *f
 }
 
-func safeString(s *string) string {
-	if s == nil {
-		return ""
+func weatherConditionOrUnknown(i *int) int {
+	if i == nil {
+		return -1
 	}
-	return *s
+	return *i
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
 }
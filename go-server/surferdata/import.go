@@ -0,0 +1,421 @@
+package surferdata
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+)
+
+// ErrInvalidImport is returned when an import can't be read at all, as opposed to single bad rows
+var ErrInvalidImport = errors.New("invalid import")
+
+// ImportFormat is a file format of the bulk import
+type ImportFormat string
+
+const (
+	ImportCSV  ImportFormat = "csv"
+	ImportJSON ImportFormat = "json"
+)
+
+const (
+	ImportAccepted = "accepted"
+	ImportRejected = "rejected"
+)
+
+// importHistoryWindow is how close stored entries have to be to lend an imported one their conditions
+const importHistoryWindow = 2 * time.Hour
+
+// importColumns are the fields an import row may have, named like the export (EntryCSVHeader)
+var importColumns = EntryCSVHeader
+
+// ImportReport tells what happened to each row of an import
+type ImportReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+type ImportRowResult struct {
+	Row        int        `json:"row"` // line in a CSV file, position (from 1) in a JSON array
+	Status     string     `json:"status"`
+	Timestamp  *time.Time `json:"timestamp,omitempty"`
+	Errors     []string   `json:"errors,omitempty"`
+	Backfilled []string   `json:"backfilled,omitempty"` // conditions taken from stored entries
+	Missing    []string   `json:"missing,omitempty"`    // conditions neither given nor found, stored as unknown
+}
+
+// importRecord is a row as read from the file, before validation
+type importRecord struct {
+	row    int
+	fields map[string]string
+	err    error
+}
+
+// importEntry is a valid row; conditions not given are nil
+type importEntry struct {
+	Timestamp        time.Time
+	Count            int
+	WaterTemperature *float64
+	AirTemperature   *float64
+	WeatherCondition *int
+	WaterLevel       *float64
+	WaterFlow        *float64
+}
+
+// ImportEntries validates each row read from r, fills in missing conditions from the entries
+// stored around the same time (never from the live APIs) and stores the valid rows as accepted
+// reports, all in one transaction. Rows at a time that already has an entry are rejected, so
+// an import can be repeated. A dry run does the same but rolls back, so its report is exact.
+func (s *Service) ImportEntries(ctx context.Context, r io.Reader, format ImportFormat, dryRun bool) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun, Rows: []ImportRowResult{}}
+
+	records, err := readImport(r, format)
+	if err != nil {
+		return report, err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	for _, record := range records {
+		result := ImportRowResult{Row: record.row, Status: ImportRejected}
+		if record.err != nil {
+			result.Errors = []string{record.err.Error()}
+		} else if entry, errs := parseImportEntry(record.fields, now); len(errs) > 0 {
+			result.Errors = errs
+		} else {
+			result.Timestamp = &entry.Timestamp
+			if result.Errors, result.Backfilled, result.Missing, err = importEntryTx(ctx, tx, &entry); err != nil {
+				return report, fmt.Errorf("row %d: %w", record.row, err)
+			}
+			if len(result.Errors) == 0 {
+				result.Status = ImportAccepted
+			}
+		}
+
+		if result.Status == ImportAccepted {
+			report.Accepted++
+		} else {
+			report.Rejected++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, tx.Commit(ctx)
+}
+
+// importEntryTx backfills and stores one valid entry. errs are reasons to reject the row.
+func importEntryTx(ctx context.Context, tx pgx.Tx, entry *importEntry) (errs, backfilled, missing []string, err error) {
+	var exists bool
+	if err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM surfer_entries WHERE timestamp = $1 AND review_status <> 'rejected')`,
+		entry.Timestamp).Scan(&exists); err != nil {
+		return
+	}
+	if exists {
+		errs = []string{"an entry at this time already exists"}
+		return
+	}
+
+	if backfilled, missing, err = backfillConditions(ctx, tx, entry.Timestamp, entry); err != nil {
+		return
+	}
+
+	// Given conditions count as measured, backfilled ones as interpolated from their neighbors
+	snap := newConditionSnapshot()
+	for field, value := range map[conditions.HistoryField]*float64{
+		conditions.HistoryWaterTemperature: entry.WaterTemperature,
+		conditions.HistoryAirTemperature:   entry.AirTemperature,
+		conditions.HistoryWaterLevel:       entry.WaterLevel,
+		conditions.HistoryWaterFlow:        entry.WaterFlow,
+	} {
+		setImported(snap, field, value, backfilled)
+	}
+	var weather *float64
+	if entry.WeatherCondition != nil {
+		w := float64(*entry.WeatherCondition)
+		weather = &w
+	}
+	setImported(snap, conditions.HistoryWeatherCondition, weather, backfilled)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO surfer_entries (timestamp, count, water_temperature, air_temperature, weather_condition, water_level, water_flow,
+		                             conditions_status, conditions_enriched_at, review_status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now(), $9)`,
+		entry.Timestamp, entry.Count,
+		snap.column(conditions.HistoryWaterTemperature), snap.column(conditions.HistoryAirTemperature),
+		snap.column(conditions.HistoryWeatherCondition), snap.column(conditions.HistoryWaterLevel),
+		snap.column(conditions.HistoryWaterFlow), snap.Status, ReviewAccepted)
+	return
+}
+
+func setImported(snap ConditionSnapshot, field conditions.HistoryField, value *float64, backfilled []string) {
+	switch {
+	case value == nil:
+		snap.set(field, 0, ConditionMissing)
+	case slices.Contains(backfilled, string(field)):
+		snap.set(field, *value, ConditionInterpolated)
+	default:
+		snap.set(field, *value, ConditionMeasured)
+	}
+}
+
+// backfillConditions takes each condition the entry lacks from the closest stored entry that has it.
+// Zeros and -1 in stored entries are placeholders of failed fetches, not measurements.
+func backfillConditions(ctx context.Context, tx pgx.Tx, at time.Time, entry *importEntry) (backfilled, missing []string, err error) {
+	if entry.WaterTemperature != nil && entry.AirTemperature != nil && entry.WeatherCondition != nil &&
+		entry.WaterLevel != nil && entry.WaterFlow != nil {
+		return nil, nil, nil
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT water_temperature, air_temperature, weather_condition, water_level, water_flow
+		 FROM surfer_entries
+		 WHERE `+countedEntries+` AND timestamp BETWEEN $1 AND $2
+		 ORDER BY abs(extract(epoch FROM timestamp - $3::timestamptz))`,
+		at.Add(-importHistoryWindow), at.Add(importHistoryWindow), at)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	fill := func(field string, target **float64, value *float64) {
+		if *target == nil && value != nil {
+			*target = value
+			backfilled = append(backfilled, field)
+		}
+	}
+	measured := func(v float64, ok bool) *float64 {
+		if !ok {
+			return nil
+		}
+		return &v
+	}
+	for rows.Next() {
+		var (
+			waterTemp, airTemp    float64
+			weather               int
+			waterLevel, waterFlow *float64
+		)
+		if err := rows.Scan(&waterTemp, &airTemp, &weather, &waterLevel, &waterFlow); err != nil {
+			return nil, nil, err
+		}
+		fill("water_temperature", &entry.WaterTemperature, measured(waterTemp, waterTemp != 0))
+		fill("air_temperature", &entry.AirTemperature, measured(airTemp, weather != -1))
+		if entry.WeatherCondition == nil && weather != -1 {
+			entry.WeatherCondition = &weather
+			backfilled = append(backfilled, "weather_condition")
+		}
+		if waterLevel != nil {
+			fill("water_level", &entry.WaterLevel, measured(*waterLevel, *waterLevel != 0))
+		}
+		if waterFlow != nil {
+			fill("water_flow", &entry.WaterFlow, measured(*waterFlow, *waterFlow != 0))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	for field, isMissing := range map[string]bool{
+		"water_temperature": entry.WaterTemperature == nil,
+		"air_temperature":   entry.AirTemperature == nil,
+		"weather_condition": entry.WeatherCondition == nil,
+		"water_level":       entry.WaterLevel == nil,
+		"water_flow":        entry.WaterFlow == nil,
+	} {
+		if isMissing {
+			missing = append(missing, field)
+		}
+	}
+	slices.Sort(backfilled)
+	slices.Sort(missing)
+	return backfilled, missing, nil
+}
+
+// parseImportEntry validates the fields of one row. Timestamps without an offset are spot-local time.
+func parseImportEntry(fields map[string]string, now time.Time) (importEntry, []string) {
+	var (
+		entry importEntry
+		errs  []string
+	)
+
+	if raw := fields["timestamp"]; raw == "" {
+		errs = append(errs, "timestamp is required")
+	} else if t, err := parseImportTime(raw); err != nil {
+		errs = append(errs, err.Error())
+	} else if t.After(now) {
+		errs = append(errs, "timestamp is in the future")
+	} else {
+		entry.Timestamp = t
+	}
+
+	if raw := fields["count"]; raw == "" {
+		errs = append(errs, "count is required")
+	} else if count, err := strconv.Atoi(raw); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid count %q", raw))
+	} else if count < 0 || count > config.Anomaly.MaxCount {
+		errs = append(errs, fmt.Sprintf("count must be between 0 and %d", config.Anomaly.MaxCount))
+	} else {
+		entry.Count = count
+	}
+
+	number := func(field string, min, max float64) *float64 {
+		raw := fields[field]
+		if raw == "" {
+			return nil
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid %s %q", field, raw))
+			return nil
+		}
+		if v < min || v > max {
+			errs = append(errs, fmt.Sprintf("%s must be between %g and %g", field, min, max))
+			return nil
+		}
+		return &v
+	}
+	entry.WaterTemperature = number("water_temperature", -5, 40)
+	entry.AirTemperature = number("air_temperature", -40, 50)
+	entry.WaterLevel = number("water_level", 0, 1000)
+	entry.WaterFlow = number("water_flow", 0, 1000)
+	if weather := number("weather_condition", 0, 99); weather != nil {
+		if *weather != float64(int(*weather)) {
+			errs = append(errs, "weather_condition must be a WMO weather code")
+		} else {
+			code := int(*weather)
+			entry.WeatherCondition = &code
+		}
+	}
+
+	return entry, errs
+}
+
+var importTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+func parseImportTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, conditions.SpotLocation()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q, expected RFC 3339 or YYYY-MM-DD HH:MM", s)
+}
+
+// readImport reads the rows of a CSV file with a header line or of a JSON array of objects
+func readImport(r io.Reader, format ImportFormat) ([]importRecord, error) {
+	switch format {
+	case ImportCSV:
+		return readImportCSV(r)
+	case ImportJSON:
+		return readImportJSON(r)
+	}
+	return nil, fmt.Errorf("%w: unsupported format %q, expected csv or json", ErrInvalidImport, format)
+}
+
+func readImportCSV(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: no header line: %w", ErrInvalidImport, err)
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\uFEFF")))
+		if !slices.Contains(importColumns, header[i]) {
+			return nil, fmt.Errorf("%w: unknown column %q, expected %s", ErrInvalidImport, column, strings.Join(importColumns, ", "))
+		}
+	}
+	for _, required := range []string{"timestamp", "count"} {
+		if !slices.Contains(header, required) {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidImport, required)
+		}
+	}
+
+	records := []importRecord{}
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+		}
+
+		record := importRecord{row: line, fields: map[string]string{}}
+		if err != nil {
+			record.err = fmt.Errorf("expected %d columns, got %d", len(header), len(values))
+		} else {
+			for i, value := range values {
+				record.fields[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+func readImportJSON(r io.Reader) ([]importRecord, error) {
+	var rows []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("%w: expected a JSON array of entries: %w", ErrInvalidImport, err)
+	}
+
+	records := make([]importRecord, 0, len(rows))
+	for i, raw := range rows {
+		record := importRecord{row: i + 1, fields: map[string]string{}}
+		record.err = decodeImportObject(raw, record.fields)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// decodeImportObject flattens a JSON object into the fields of a row, like a CSV line
+func decodeImportObject(raw json.RawMessage, fields map[string]string) error {
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	var object map[string]any
+	if err := dec.Decode(&object); err != nil || object == nil {
+		return errors.New("expected an object")
+	}
+
+	for key, value := range object {
+		if !slices.Contains(importColumns, key) {
+			return fmt.Errorf("unknown field %q", key)
+		}
+		switch v := value.(type) {
+		case nil:
+		case string:
+			fields[key] = strings.TrimSpace(v)
+		case json.Number:
+			fields[key] = v.String()
+		default:
+			return fmt.Errorf("%s must be a string or a number", key)
+		}
+	}
+	return nil
+}
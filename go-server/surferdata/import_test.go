@@ -0,0 +1,122 @@
+package surferdata
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/testutils"
+)
+
+func TestReadImportCSV(t *testing.T) {
+	records, err := readImport(strings.NewReader(
+		"Timestamp,count,water_temperature\n"+
+			"2025-06-01 14:00,7,16.5\n"+
+			"2025-06-01 15:00,9\n"+
+			"2025-06-01T16:00:00+02:00,4,\n"), ImportCSV)
+	if err != nil {
+		t.Fatalf("readImport failed: %v", err)
+	}
+	for _, r := range records {
+		t.Logf("row %d: %v %v", r.row, r.fields, r.err)
+	}
+	if len(records) != 3 || records[0].row != 2 || records[0].fields["water_temperature"] != "16.5" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	if records[1].err == nil {
+		t.Error("expected a row with a missing column to be rejected on its own")
+	}
+	if records[2].err != nil || records[2].fields["water_temperature"] != "" {
+		t.Errorf("expected an empty cell to mean no value, got %+v", records[2])
+	}
+
+	for _, bad := range []string{"", "count\n3\n", "timestamp,count,tide\n"} {
+		if _, err := readImport(strings.NewReader(bad), ImportCSV); !errors.Is(err, ErrInvalidImport) {
+			t.Errorf("expected %q to be an invalid import, got %v", bad, err)
+		}
+	}
+}
+
+func TestReadImportJSON(t *testing.T) {
+	records, err := readImport(strings.NewReader(
+		`[{"timestamp": "2025-06-01 14:00", "count": 7, "water_level": null}, {"count": "3", "tide": 1}, 5]`), ImportJSON)
+	if err != nil {
+		t.Fatalf("readImport failed: %v", err)
+	}
+	if len(records) != 3 || records[0].err != nil || records[0].fields["count"] != "7" || records[0].row != 1 {
+		t.Fatalf("unexpected first record: %+v", records)
+	}
+	if _, ok := records[0].fields["water_level"]; ok {
+		t.Error("expected null to mean no value")
+	}
+	if records[1].err == nil || records[2].err == nil {
+		t.Errorf("expected unknown fields and non-objects to be rejected, got %v / %v", records[1].err, records[2].err)
+	}
+
+	if _, err := readImport(strings.NewReader(`{"count": 3}`), ImportJSON); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("expected an object to be an invalid import, got %v", err)
+	}
+}
+
+func TestParseImportEntry(t *testing.T) {
+	testutils.LoadTestConfig(t)
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	entry, errs := parseImportEntry(map[string]string{
+		"timestamp": "2025-06-01 14:00", "count": "7", "weather_condition": "3", "water_level": "143.5",
+	}, now)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if want := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC); !entry.Timestamp.Equal(want) {
+		t.Errorf("expected a local logbook time to be read as spot-local, got %s", entry.Timestamp.UTC())
+	}
+	if entry.Count != 7 || *entry.WeatherCondition != 3 || *entry.WaterLevel != 143.5 || entry.WaterTemperature != nil {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	cases := map[string]map[string]string{
+		"missing count":       {"timestamp": "2025-06-01 14:00"},
+		"future":              {"timestamp": "2025-08-01 14:00", "count": "3"},
+		"negative count":      {"timestamp": "2025-06-01 14:00", "count": "-1"},
+		"bad timestamp":       {"timestamp": "01.06.2025", "count": "3"},
+		"fractional weather":  {"timestamp": "2025-06-01 14:00", "count": "3", "weather_condition": "2.5"},
+		"implausible water":   {"timestamp": "2025-06-01 14:00", "count": "3", "water_temperature": "80"},
+		"non-numeric level":   {"timestamp": "2025-06-01 14:00", "count": "3", "water_level": "high"},
+		"count above maximum": {"timestamp": "2025-06-01 14:00", "count": "500"},
+	}
+	for name, fields := range cases {
+		if _, errs := parseImportEntry(fields, now); len(errs) == 0 {
+			t.Errorf("%s: expected the row to be rejected", name)
+		} else {
+			t.Logf("%s: %v", name, errs)
+		}
+	}
+}
+
+func TestImportEntriesDryRun(t *testing.T) {
+	service := setupTestService(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := service.DB.Ping(ctx); err != nil {
+		t.Skipf("test database unavailable: %v", err)
+	}
+
+	csv := "timestamp,count\n2020-01-01 10:00,3\n2020-01-01 10:00,4\n2020-01-01 11:00,-2\n"
+	report, err := service.ImportEntries(context.Background(), strings.NewReader(csv), ImportCSV, true)
+	if err != nil {
+		t.Fatalf("ImportEntries failed: %v", err)
+	}
+	t.Logf("%+v", report)
+	if report.Accepted != 1 || report.Rejected != 2 || report.Rows[1].Status != ImportRejected {
+		t.Errorf("expected the first row accepted and a duplicate and an invalid row rejected, got %+v", report)
+	}
+
+	// Nothing was stored, so the same import is accepted again
+	again, err := service.ImportEntries(context.Background(), strings.NewReader(csv), ImportCSV, true)
+	if err != nil || again.Accepted != 1 {
+		t.Errorf("expected a dry run to store nothing, got %+v, %v", again, err)
+	}
+}
@@ -2,19 +2,36 @@ package surferdata
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/health"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/logging"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// mlClient bounds the Flask call even when the caller's context has no deadline
+var mlClient = &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(logging.Transport(nil))}
+
 type MLPredictionParams struct {
 	Hour             int     `json:"hour"`
 	WaterTemp        float64 `json:"water_temp"`
 	AirTemp          float64 `json:"air_temp"`
 	WaterLevel       float64 `json:"water_level"`
 	WeatherCondition int     `json:"weather_condition"`
+	IsDaylight       int     `json:"is_daylight"`
+	DayLengthHours   float64 `json:"day_length_hours"`
+	Weekday          int     `json:"weekday"` // 0 = Monday
+	IsWeekend        int     `json:"is_weekend"`
+	IsPublicHoliday  int     `json:"is_public_holiday"`
+	IsSchoolHoliday  int     `json:"is_school_holiday"`
 }
 
 type MLPredictionResponse struct {
@@ -22,24 +39,53 @@ type MLPredictionResponse struct {
 	Explanation map[string]float64 `json:"explanation"` // Add explanation field
 }
 
-func (s *Service) PredictSurferCountML(params MLPredictionParams) (int, map[string]float64, error) {
-	// Prepare the request payload
-	payload := map[string]interface{}{
+// PredictSurferCountML asks the ML service configured by FLASK_API_URL, used when no model is active in the registry
+func (s *Service) PredictSurferCountML(ctx context.Context, params MLPredictionParams) (int, map[string]float64, error) {
+	return callModel(ctx, os.Getenv("FLASK_API_URL"), params.features())
+}
+
+// features is the full set of model inputs, keyed by feature name
+func (params MLPredictionParams) features() map[string]any {
+	return map[string]any{
 		"hour":              params.Hour,
 		"water_temp":        params.WaterTemp,
 		"air_temp":          params.AirTemp,
 		"water_level":       params.WaterLevel,
 		"weather_condition": params.WeatherCondition, // Use numeric weather_condition
+		"is_daylight":       params.IsDaylight,
+		"day_length_hours":  params.DayLengthHours,
+		"weekday":           params.Weekday,
+		"is_weekend":        params.IsWeekend,
+		"is_public_holiday": params.IsPublicHoliday,
+		"is_school_holiday": params.IsSchoolHoliday,
 	}
+}
 
+// callModel asks one model of the ML service for a prediction
+func callModel(ctx context.Context, url string, payload map[string]any) (int, map[string]float64, error) {
+	ctx, span := tracing.Start(ctx, "ml.predict", attribute.String("ml.endpoint", url))
+	started := time.Now()
+	count, explanation, err := requestModel(ctx, url, payload)
+	metrics.ObserveUpstream(metrics.ProviderMLService, time.Since(started), err)
+	health.Upstreams.Observe(metrics.ProviderMLService, err)
+	span.SetAttributes(attribute.Int("ml.surfer_count", count))
+	tracing.End(span, err)
+	return count, explanation, err
+}
+
+func requestModel(ctx context.Context, url string, payload map[string]any) (int, map[string]float64, error) {
 	// Convert payload to JSON
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		return 0, nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
-	url := os.Getenv("FLASK_API_URL")
 	// Make the HTTP POST request to the Flask API
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonPayload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to build ML prediction request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := mlClient.Do(req)
 	if err != nil {
 		return 0, nil, fmt.Errorf("failed to call ML prediction API: %w", err)
 	}
@@ -0,0 +1,88 @@
+package surferdata
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/modelregistry"
+)
+
+// shadowTimeout bounds shadow calls, which outlive the request that triggered them
+const shadowTimeout = 10 * time.Second
+
+// mlResult is the answer of the model that served a prediction
+type mlResult struct {
+	Count        int
+	Explanation  map[string]float64
+	ModelVersion string // empty for the FLASK_API_URL fallback
+}
+
+// predictML asks the active model of the registry and runs the shadow models alongside.
+// Shadow outputs are only logged. Without a registry or an active model FLASK_API_URL is used.
+func (s *Service) predictML(ctx context.Context, params MLPredictionParams) (mlResult, error) {
+	if s.Models == nil {
+		count, explanation, err := s.PredictSurferCountML(ctx, params)
+		return mlResult{Count: count, Explanation: explanation}, err
+	}
+
+	active, shadows, err := s.Models.Serving(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "could not load models from registry", "err", err)
+	}
+
+	features := params.features()
+	activeDone := make(chan struct{})
+	var activePrediction *int
+	for _, shadow := range shadows {
+		go s.runShadow(context.WithoutCancel(ctx), shadow, active, features, activeDone, &activePrediction)
+	}
+	defer close(activeDone)
+
+	if active == nil {
+		count, explanation, err := s.PredictSurferCountML(ctx, params)
+		if err == nil {
+			activePrediction = &count
+		}
+		return mlResult{Count: count, Explanation: explanation}, err
+	}
+
+	count, explanation, err := callModel(ctx, active.EndpointURL, active.Features(features))
+	if err != nil {
+		return mlResult{}, err
+	}
+	activePrediction = &count
+	return mlResult{Count: count, Explanation: explanation, ModelVersion: active.Version}, nil
+}
+
+// runShadow calls a shadow model and logs its output once the active model has answered
+func (s *Service) runShadow(ctx context.Context, shadow modelregistry.Model, active *modelregistry.Model, features map[string]any, activeDone <-chan struct{}, activePrediction **int) {
+	ctx, cancel := context.WithTimeout(ctx, shadowTimeout)
+	defer cancel()
+
+	input := shadow.Features(features)
+	started := time.Now()
+	count, _, err := callModel(ctx, shadow.EndpointURL, input)
+
+	entry := modelregistry.ShadowPrediction{
+		ModelID:   shadow.ID,
+		Features:  input,
+		LatencyMs: int(time.Since(started).Milliseconds()),
+	}
+	if err != nil {
+		msg := err.Error()
+		entry.Error = &msg
+	} else {
+		entry.Prediction = &count
+	}
+
+	<-activeDone
+	entry.ActivePrediction = *activePrediction
+	if active != nil {
+		entry.ActiveModelID = &active.ID
+	}
+
+	if err := s.Models.LogShadowPrediction(ctx, entry); err != nil {
+		slog.WarnContext(ctx, "could not log shadow prediction", "model_version", shadow.Version, "err", err)
+	}
+}
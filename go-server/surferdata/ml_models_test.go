@@ -0,0 +1,36 @@
+package surferdata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/modelregistry"
+)
+
+func TestCallModelWithFeatureSchema(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode(MLPredictionResponse{SurferCount: 9, Explanation: map[string]float64{"hour": 1.5}})
+	}))
+	defer server.Close()
+
+	model := modelregistry.Model{Version: "2025-06-weekday", EndpointURL: server.URL, FeatureSchema: []string{"hour", "weekday", "is_weekend"}}
+	params := MLPredictionParams{Hour: 7, WaterTemp: 15.5, Weekday: 5, IsWeekend: 1}
+
+	count, explanation, err := callModel(context.Background(), model.EndpointURL, model.Features(params.features()))
+	if err != nil {
+		t.Fatalf("callModel failed: %v", err)
+	}
+
+	t.Logf("sent %v, got %d", received, count)
+	if count != 9 || explanation["hour"] != 1.5 {
+		t.Errorf("unexpected model answer: %d %v", count, explanation)
+	}
+	if len(received) != 3 || received["weekday"] != float64(5) {
+		t.Errorf("expected only the schema's features to be sent, got %v", received)
+	}
+}
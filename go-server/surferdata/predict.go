@@ -2,14 +2,22 @@ package surferdata
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"math"
+	"time"
 
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/calendar"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type PredictionParams struct {
-	Hour             int
+	Hour int
+	Time time.Time // hour:00 of the predicted day; defaults to today
+
 	WaterTemp        *float64
 	AirTemp          *float64
 	WeatherCondition int
@@ -17,35 +25,56 @@ type PredictionParams struct {
 	WaterFlow        float64
 }
 
-// BasePredictionByHour fetches avg surfer count from DB for given hour
-func (s *Service) basePredictionByHour(hour int) (float64, error) {
-	var avg *float64
-	err := s.DB.QueryRow(context.Background(),
-		`SELECT AVG(count) FROM surfer_entries WHERE EXTRACT(HOUR FROM timestamp) = $1`,
-		hour,
-	).Scan(&avg)
+// BasePredictionByHour fetches avg surfer count from DB for given hour of the spot's local time
+func (s *Service) basePredictionByHour(ctx context.Context, hour int, night bool) (float64, error) {
+	ctx, span := tracing.Start(ctx, "surferdata.basePredictionByHour", attribute.Int("hour", hour))
+	defer span.End()
 
+	groups, err := s.aggregateCounts(ctx, EntryFilter{}, meanAtHour(hour))
 	if err != nil {
 		return 0, err
 	}
+	avg := groups[0].Mean
 
 	// fallback logic for weird hours (no data or tiny value)
-	if avg == nil || *avg < 1 {
+	if avg < 1 {
 		// night hours fallback (basically no one)
-		if hour >= 22 || hour <= 5 {
+		if night {
 			return 0, nil // super low base
 		}
 		return 1, nil // minimal base for daytime
 	}
 
-	return *avg, nil
+	return avg, nil
+}
+
+// PredictionResponse is the result of PredictSurferCountAdvanced, together with the inputs it was computed from
+type PredictionResponse struct {
+	Hour             int                `json:"hour"`
+	WaterTemperature float64            `json:"water_temperature"`
+	AirTemperature   float64            `json:"air_temperature"`
+	WeatherCondition int                `json:"weather_condition"` // WMO code, -1 if unknown
+	WaterLevel       float64            `json:"water_level"`
+	IsDaylight       bool               `json:"is_daylight"`
+	Prediction       int                `json:"prediction"`
+	Source           string             `json:"source"` // ml | rule_based
+	ModelVersion     string             `json:"model_version"`
+	Explanation      map[string]float64 `json:"explanation"` // per-feature contributions; the calendar's for rule-based predictions
+	Calendar         calendar.Day       `json:"calendar"`
 }
 
-func (s *Service) PredictSurferCountAdvanced(params PredictionParams) (interface{}, error) {
+func (s *Service) PredictSurferCountAdvanced(ctx context.Context, params PredictionParams) (PredictionResponse, error) {
+	at := params.Time
+	if at.IsZero() {
+		at = conditions.AtLocalHour(time.Now(), params.Hour)
+	}
+	daylight := conditions.SunTimes(at, config.Spot.Latitude, config.Spot.Longitude)
+	day := calendar.For(at)
+
 	// Step 1: Get the base prediction by hour (rule-based fallback)
-	base, err := s.basePredictionByHour(params.Hour)
+	base, err := s.basePredictionByHour(ctx, params.Hour, isNight(params.Hour, &daylight))
 	if err != nil {
-		return 0, err
+		return PredictionResponse{}, err
 	}
 
 	// Step 2: Calculate the rule-based factor
@@ -53,7 +82,7 @@ func (s *Service) PredictSurferCountAdvanced(params PredictionParams) (interface
 		Temp:      safeFloat(params.AirTemp),
 		Condition: params.WeatherCondition,
 	}
-	factor := calculateFactor(params.Hour, params.WaterTemp, weatherData, params.WaterLevel, params.WaterFlow)
+	factor := calculateFactor(params.Hour, params.WaterTemp, weatherData, params.WaterLevel, params.WaterFlow, &daylight, &day)
 	ruleBasedPrediction := int(math.Round(base * factor))
 	if ruleBasedPrediction < 0 {
 		ruleBasedPrediction = 0
@@ -66,29 +95,45 @@ func (s *Service) PredictSurferCountAdvanced(params PredictionParams) (interface
 		AirTemp:          safeFloat(params.AirTemp),
 		WaterLevel:       params.WaterLevel,
 		WeatherCondition: params.WeatherCondition,
+		IsDaylight:       boolToInt(!isNight(params.Hour, &daylight)),
+		DayLengthHours:   daylight.DayHours,
+		Weekday:          day.MondayBasedWeekday(),
+		IsWeekend:        boolToInt(day.IsWeekend),
+		IsPublicHoliday:  boolToInt(day.IsPublicHoliday()),
+		IsSchoolHoliday:  boolToInt(day.IsSchoolHoliday()),
 	}
-	mlPrediction, explanation, err := s.PredictSurferCountML(mlParams)
+	// Fall back to the rule-based prediction if the ML service is down or too slow
+	prediction, source := ruleBasedPrediction, "rule_based"
+	explanation := ruleBasedExplanation(base, &day)
+	ml, err := s.predictML(ctx, mlParams)
 	if err != nil {
-		fmt.Printf("Error predicting surfer count: %v\n", err)
-		return 0, err
-	}
+		slog.WarnContext(ctx, "ML prediction unavailable, using rule-based prediction", "err", err)
+	} else {
+		prediction, source, explanation = ml.Count, "ml", ml.Explanation
 
-	fmt.Printf("Predicted Surfer Count: %d\n", mlPrediction)
-	fmt.Println("Feature Contributions:")
-	for feature, contribution := range explanation {
-		fmt.Printf("  %s: %.2f\n", feature, contribution)
+		contributions := make([]any, 0, len(ml.Explanation))
+		for feature, contribution := range ml.Explanation {
+			contributions = append(contributions, slog.Float64(feature, contribution))
+		}
+		slog.DebugContext(ctx, "ML prediction",
+			"count", ml.Count, "model_version", ml.ModelVersion, slog.Group("contributions", contributions...))
 	}
+	metrics.Predictions.WithLabelValues(source).Inc()
 
 	// Step 4: Combine the predictions (optional)
 	// Combine the response
-	response := map[string]interface{}{
-		"hour":              params.Hour,
-		"water_temperature": safeFloat(params.WaterTemp),
-		"air_temperature":   safeFloat(params.AirTemp),
-		"weather_condition": params.WeatherCondition,
-		"water_level":       params.WaterLevel,
-		"prediction":        mlPrediction,
-		"explanation":       explanation,
+	response := PredictionResponse{
+		Hour:             params.Hour,
+		WaterTemperature: safeFloat(params.WaterTemp),
+		AirTemperature:   safeFloat(params.AirTemp),
+		WeatherCondition: params.WeatherCondition,
+		WaterLevel:       params.WaterLevel,
+		IsDaylight:       !isNight(params.Hour, &daylight),
+		Prediction:       prediction,
+		Source:           source,
+		ModelVersion:     ml.ModelVersion,
+		Explanation:      explanation,
+		Calendar:         day,
 	}
 
 	return response, nil
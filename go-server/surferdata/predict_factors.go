@@ -1,21 +1,30 @@
 package surferdata
 
 import (
+	"math"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/calendar"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
 )
 
-// calculateFactor applies all dynamic factors based on the current context
+// calculateFactor applies all dynamic factors based on the current context.
+// daylight and day are optional; without daylight night is assumed to be 22–5h.
 func calculateFactor(
 	hour int,
 	waterTemp *float64,
 	weatherData *conditions.WeatherData,
 	waterLevel float64,
 	waterFlow float64,
+	daylight *conditions.Daylight,
+	day *calendar.Day,
 ) float64 {
 	factor := 1.0
 
 	// 🕒 Time of day influence
-	if hour >= 6 && hour <= 8 {
+	if daylight != nil {
+		factor += daylightFactor(hour, daylight)
+	} else if hour >= 6 && hour <= 8 {
 		factor += 0.3 // Early morning surf crowd
 	} else if hour >= 12 && hour <= 14 {
 		factor += 0.2 // Lunchtime bump
@@ -23,6 +32,11 @@ func calculateFactor(
 		factor -= 0.8 // Night time drop
 	}
 
+	// 📅 Calendar influence
+	for _, contribution := range calendarFactors(day) {
+		factor += contribution
+	}
+
 	// ❄️ Water temperature influence
 	if waterTemp != nil && *waterTemp < 10 {
 		factor -= 0.2
@@ -58,3 +72,55 @@ func calculateFactor(
 
 	return factor
 }
+
+// calendarFactors are the calendar's contributions to the factor, keyed like the ML features.
+// Every feature is present, with 0 if the day doesn't have it or another one applies.
+func calendarFactors(day *calendar.Day) map[string]float64 {
+	factors := map[string]float64{"is_weekend": 0, "is_public_holiday": 0, "is_school_holiday": 0}
+	switch {
+	case day == nil:
+	case day.IsPublicHoliday():
+		factors["is_public_holiday"] = 0.3 // Day off crowd, on weekends too
+	case day.IsWeekend:
+		factors["is_weekend"] = 0.3
+	case day.IsSchoolHoliday():
+		factors["is_school_holiday"] = 0.1 // Students have time on weekdays
+	}
+	return factors
+}
+
+// ruleBasedExplanation is the calendar's contribution to a rule-based prediction in surfers,
+// before the factor is capped
+func ruleBasedExplanation(base float64, day *calendar.Day) map[string]float64 {
+	explanation := calendarFactors(day)
+	for feature, contribution := range explanation {
+		explanation[feature] = math.Round(base*contribution*100) / 100
+	}
+	return explanation
+}
+
+// daylightFactor is the time-of-day influence using the actual sun times of the day
+func daylightFactor(hour int, daylight *conditions.Daylight) float64 {
+	noon := daylight.SolarNoon
+	midHour := time.Date(noon.Year(), noon.Month(), noon.Day(), hour, 30, 0, 0, noon.Location())
+
+	switch {
+	case isNight(hour, daylight):
+		return -0.8 // Night time drop
+	case hour >= 6 && hour <= 8 && daylight.IsSunUp(midHour):
+		return 0.3 // Early morning surf crowd, only once the sun is up
+	case hour >= 12 && hour <= 14:
+		return 0.2 // Lunchtime bump
+	}
+	return 0
+}
+
+// isNight reports whether the given hour is dark (before civil dawn or after civil dusk)
+func isNight(hour int, daylight *conditions.Daylight) bool {
+	if daylight == nil {
+		return hour >= 22 || hour <= 5
+	}
+	noon := daylight.SolarNoon
+	midHour := time.Date(noon.Year(), noon.Month(), noon.Day(), hour, 30, 0, 0, noon.Location())
+	return !daylight.IsLight(midHour)
+}
@@ -2,7 +2,9 @@ package surferdata
 
 import (
 	"testing"
+	"time"
 
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/calendar"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/testutils"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/utils"
@@ -17,6 +19,8 @@ func TestCalculateFactorSunnyWarmPeak(t *testing.T) {
 		&conditions.WeatherData{Temp: 25, Condition: 0}, // clear
 		146, // water level
 		15,  // water flow (ignored for now)
+		nil, // no daylight: fixed night hours
+		nil, // no calendar
 	)
 
 	t.Logf("factor: %.2f", f)
@@ -35,6 +39,8 @@ func TestCalculateFactorColdRainyOffpeak(t *testing.T) {
 		&conditions.WeatherData{Temp: 5, Condition: 61}, // rain
 		135, // water level
 		10,  // water flow (ignored for now)
+		nil, // no daylight: fixed night hours
+		nil, // no calendar
 	)
 
 	t.Logf("factor: %.2f", f)
@@ -47,7 +53,7 @@ func TestCalculateFactorColdRainyOffpeak(t *testing.T) {
 func TestCalculateFactorLowWaterLevel(t *testing.T) {
 	testutils.LoadTestConfig(t)
 
-	f := calculateFactor(10, utils.Float64(15), &conditions.WeatherData{Temp: 15, Condition: 0}, 135, 10) // weather = clear
+	f := calculateFactor(10, utils.Float64(15), &conditions.WeatherData{Temp: 15, Condition: 0}, 135, 10, nil, nil) // weather = clear
 
 	t.Logf("factor: %.2f", f)
 
@@ -55,3 +61,61 @@ func TestCalculateFactorLowWaterLevel(t *testing.T) {
 		t.Error("Expected factor to decrease for low water level")
 	}
 }
+
+func TestCalculateFactorNoEarlyMorningBonusInDecemberDarkness(t *testing.T) {
+	testutils.LoadTestConfig(t)
+
+	berlin := conditions.SpotLocation()
+	december := conditions.SunTimes(time.Date(2025, 12, 15, 0, 0, 0, 0, berlin), 48.137154, 11.576124)
+	june := conditions.SunTimes(time.Date(2025, 6, 15, 0, 0, 0, 0, berlin), 48.137154, 11.576124)
+	weather := &conditions.WeatherData{Temp: 15, Condition: 0}
+
+	winter := calculateFactor(6, utils.Float64(15), weather, 143, 10, &december, nil)
+	summer := calculateFactor(6, utils.Float64(15), weather, 143, 10, &june, nil)
+
+	t.Logf("6am factor: december %.2f, june %.2f", winter, summer)
+
+	if winter >= 1.0 {
+		t.Error("Expected no early morning bonus before sunrise in December")
+	}
+	if summer <= 1.0 {
+		t.Error("Expected the early morning bonus after sunrise in June")
+	}
+}
+
+func TestCalculateFactorHolidayBoost(t *testing.T) {
+	testutils.LoadTestConfig(t)
+
+	berlin := conditions.SpotLocation()
+	weather := &conditions.WeatherData{Temp: 15, Condition: 0}
+	holiday := calendar.For(time.Date(2025, 6, 19, 10, 0, 0, 0, berlin)) // Fronleichnam, Thursday
+	workday := calendar.For(time.Date(2025, 10, 14, 10, 0, 0, 0, berlin))
+
+	onHoliday := calculateFactor(10, utils.Float64(15), weather, 143, 10, nil, &holiday)
+	onWorkday := calculateFactor(10, utils.Float64(15), weather, 143, 10, nil, &workday)
+
+	t.Logf("factor: holiday %.2f, workday %.2f", onHoliday, onWorkday)
+
+	if onHoliday <= onWorkday {
+		t.Error("Expected a public holiday to attract more surfers than a workday")
+	}
+}
+
+func TestRuleBasedExplanationShowsCalendar(t *testing.T) {
+	testutils.LoadTestConfig(t)
+
+	holiday := calendar.For(time.Date(2025, 6, 19, 10, 0, 0, 0, conditions.SpotLocation())) // Fronleichnam, Thursday
+	saturday := calendar.For(time.Date(2025, 10, 18, 10, 0, 0, 0, conditions.SpotLocation()))
+
+	explanation := ruleBasedExplanation(10, &holiday)
+	t.Logf("%v", explanation)
+	if explanation["is_public_holiday"] != 3 || explanation["is_weekend"] != 0 || len(explanation) != 3 {
+		t.Errorf("expected the holiday to add 3 of 10 surfers, got %v", explanation)
+	}
+	if explanation := ruleBasedExplanation(10, &saturday); explanation["is_weekend"] != 3 {
+		t.Errorf("expected the weekend to add 3 of 10 surfers, got %v", explanation)
+	}
+	if explanation := ruleBasedExplanation(10, nil); explanation["is_weekend"] != 0 || len(explanation) != 3 {
+		t.Errorf("expected every calendar feature without effect, got %v", explanation)
+	}
+}
@@ -1,6 +1,7 @@
 package surferdata
 
 import (
+	"context"
 	"testing"
 
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/utils"
@@ -8,20 +9,20 @@ import (
 
 func TestPredictSurferCount_HourOnly(t *testing.T) {
 	service := setupTestService(t)
-	pred, err := service.PredictSurferCountAdvanced(PredictionParams{
+	pred, err := service.PredictSurferCountAdvanced(context.Background(), PredictionParams{
 		Hour: 14,
 	})
 	if err != nil {
 		t.Fatalf("Prediction failed: %v", err)
 	}
 
-	t.Logf("Prediction for hour=14 → %d", pred)
+	t.Logf("Prediction for hour=14 → %d", pred.Prediction)
 }
 
 func TestPredictSurferCount_WithWaterTemp(t *testing.T) {
 	service := setupTestService(t)
 
-	pred, err := service.PredictSurferCountAdvanced(PredictionParams{
+	pred, err := service.PredictSurferCountAdvanced(context.Background(), PredictionParams{
 		Hour:      18,
 		WaterTemp: utils.Float64(18),
 	})
@@ -29,13 +30,13 @@ func TestPredictSurferCount_WithWaterTemp(t *testing.T) {
 		t.Fatalf("Prediction failed: %v", err)
 	}
 
-	t.Logf("Prediction for hour=18 with 18°C water temp → %d", pred)
+	t.Logf("Prediction for hour=18 with 18°C water temp → %d", pred.Prediction)
 }
 
 func TestPredictSurferCount_AllFactorsSunny(t *testing.T) {
 	service := setupTestService(t)
 
-	pred, err := service.PredictSurferCountAdvanced(PredictionParams{
+	pred, err := service.PredictSurferCountAdvanced(context.Background(), PredictionParams{
 		Hour:             14,
 		WaterTemp:        utils.Float64(18),
 		AirTemp:          utils.Float64(25),
@@ -45,13 +46,13 @@ func TestPredictSurferCount_AllFactorsSunny(t *testing.T) {
 		t.Fatalf("Prediction failed: %v", err)
 	}
 
-	t.Logf("Prediction for hour=14 sunny warm → %d", pred)
+	t.Logf("Prediction for hour=14 sunny warm → %d", pred.Prediction)
 }
 
 func TestPredictSurferCount_AllFactorsBad(t *testing.T) {
 	service := setupTestService(t)
 
-	pred, err := service.PredictSurferCountAdvanced(PredictionParams{
+	pred, err := service.PredictSurferCountAdvanced(context.Background(), PredictionParams{
 		Hour:             5,
 		WaterTemp:        utils.Float64(4),
 		AirTemp:          utils.Float64(2),
@@ -61,5 +62,5 @@ func TestPredictSurferCount_AllFactorsBad(t *testing.T) {
 		t.Fatalf("Prediction failed: %v", err)
 	}
 
-	t.Logf("Prediction for hour=5 cold rainy → %d", pred)
+	t.Logf("Prediction for hour=5 cold rainy → %d", pred.Prediction)
 }
@@ -0,0 +1,66 @@
+package surferdata
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrEntryNotFound = errors.New("entry not found")
+
+// QueuedEntry is a surfer report waiting in the review queue
+type QueuedEntry struct {
+	ID               int       `json:"id"`
+	Timestamp        time.Time `json:"timestamp"`
+	Count            int       `json:"count"`
+	WaterTemperature float64   `json:"water_temperature"`
+	AirTemperature   float64   `json:"air_temperature"`
+	WaterLevel       float64   `json:"water_level"`
+	ReviewStatus     string    `json:"review_status"`
+	AnomalyScore     float64   `json:"anomaly_score"`
+	AnomalyReason    string    `json:"anomaly_reason"`
+}
+
+// GetReviewQueue returns flagged and quarantined reports that weren't reviewed yet, quarantined first
+func (s *Service) GetReviewQueue(ctx context.Context) ([]QueuedEntry, error) {
+	rows, err := s.DB.Query(ctx,
+		`SELECT id, timestamp, count, water_temperature, air_temperature, COALESCE(water_level, 0),
+		        review_status, COALESCE(anomaly_score, 0), COALESCE(anomaly_reason, '')
+		 FROM surfer_entries
+		 WHERE review_status IN ('flagged', 'quarantined') AND reviewed_at IS NULL
+		 ORDER BY review_status = 'quarantined' DESC, timestamp DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queue := []QueuedEntry{}
+	for rows.Next() {
+		var e QueuedEntry
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Count, &e.WaterTemperature, &e.AirTemperature, &e.WaterLevel,
+			&e.ReviewStatus, &e.AnomalyScore, &e.AnomalyReason); err != nil {
+			return nil, err
+		}
+		queue = append(queue, e)
+	}
+	return queue, rows.Err()
+}
+
+// ReviewEntry accepts (counted from now on) or rejects (never counted) a report
+func (s *Service) ReviewEntry(ctx context.Context, id int, accept bool) (string, error) {
+	status := ReviewRejected
+	if accept {
+		status = ReviewAccepted
+	}
+
+	var updated string
+	err := s.DB.QueryRow(ctx,
+		`UPDATE surfer_entries SET review_status = $2, reviewed_at = now() WHERE id = $1 RETURNING review_status`,
+		id, status).Scan(&updated)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrEntryNotFound
+	}
+	return updated, err
+}
@@ -0,0 +1,211 @@
+package surferdata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+)
+
+// WaterLevelBand is the width of the water level bands crowd statistics are grouped by
+const WaterLevelBand = 10 // cm
+
+// CountStats summarizes the counts of a group of surfer reports
+type CountStats struct {
+	Reports int     `json:"reports"`
+	Mean    float64 `json:"mean"`
+	Median  float64 `json:"median"`
+	P90     float64 `json:"p90"`
+}
+
+type WeekdayHourStats struct {
+	Weekday int `json:"weekday"` // 0 = Monday
+	Hour    int `json:"hour"`
+	CountStats
+}
+
+type MonthStats struct {
+	Month int `json:"month"` // 1 = January
+	CountStats
+}
+
+type WeatherStats struct {
+	WeatherCondition int `json:"weather_condition"` // WMO code
+	CountStats
+}
+
+type WaterLevelStats struct {
+	LevelFrom int `json:"level_from"` // cm, inclusive
+	LevelTo   int `json:"level_to"`   // cm, exclusive
+	CountStats
+}
+
+// CrowdStats are the surfer counts of a time range, grouped for heatmaps. Hours, weekdays
+// and months are local to the spot; groups without reports are left out.
+type CrowdStats struct {
+	From          *time.Time         `json:"from,omitempty"`
+	To            *time.Time         `json:"to,omitempty"`
+	TimeZone      string             `json:"time_zone"`
+	Overall       CountStats         `json:"overall"`
+	ByWeekdayHour []WeekdayHourStats `json:"by_weekday_hour"`
+	ByMonth       []MonthStats       `json:"by_month"`
+	ByWeather     []WeatherStats     `json:"by_weather"`
+	ByWaterLevel  []WaterLevelStats  `json:"by_water_level"`
+}
+
+// countGrouping is a way to group counted reports: SQL expressions for the integer group keys
+// and a condition for the reports that have them, with its arguments from $4 on. local_time is
+// the spot's wall clock. meanOnly skips the median and P90, they stay zero.
+type countGrouping struct {
+	keys     []string
+	where    string
+	args     []any
+	meanOnly bool
+}
+
+var (
+	groupOverall     = countGrouping{}
+	groupByMonth     = countGrouping{keys: []string{`EXTRACT(MONTH FROM local_time)::int`}}
+	groupByWeekdayHr = countGrouping{keys: []string{
+		`EXTRACT(ISODOW FROM local_time)::int - 1`,
+		`EXTRACT(HOUR FROM local_time)::int`,
+	}}
+	// Placeholders of failed fetches aren't conditions
+	groupByWeather = countGrouping{
+		keys:  []string{`weather_condition`},
+		where: `conditions_status->>'weather_condition' IN ('measured', 'interpolated')`,
+	}
+	groupByWaterLevel = countGrouping{
+		keys:  []string{fmt.Sprintf(`floor(water_level / %d)::int * %d`, WaterLevelBand, WaterLevelBand)},
+		where: `water_level IS NOT NULL AND conditions_status->>'water_level' IN ('measured', 'interpolated')`,
+	}
+)
+
+// meanAtHour is the mean count of the reports at the spot's local hour, without grouping all
+// hours first; predictions ask for it on every call.
+func meanAtHour(hour int) countGrouping {
+	return countGrouping{where: `EXTRACT(HOUR FROM local_time) = $4`, args: []any{hour}, meanOnly: true}
+}
+
+// countGroup is one group of an aggregation
+type countGroup struct {
+	keys []int
+	CountStats
+}
+
+// aggregateCounts computes the count statistics of every group of counted reports in the
+// filter's time range, ordered by key. Without keys there is exactly one group, empty or not.
+func (s *Service) aggregateCounts(ctx context.Context, filter EntryFilter, grouping countGrouping) ([]countGroup, error) {
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+
+	where := "TRUE"
+	if grouping.where != "" {
+		where = grouping.where
+	}
+	columns := append(append([]string{}, grouping.keys...), `COUNT(*)`, `COALESCE(AVG(count), 0)::float8`)
+	if !grouping.meanOnly {
+		columns = append(columns,
+			`COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY count), 0)`,
+			`COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY count), 0)`)
+	}
+	query := `WITH counted AS (
+		SELECT count, timestamp AT TIME ZONE $3 AS local_time, weather_condition, water_level, conditions_status
+		FROM surfer_entries
+		WHERE ` + countedEntries + `
+		  AND ($1::timestamptz IS NULL OR timestamp >= $1)
+		  AND ($2::timestamptz IS NULL OR timestamp < $2)
+	)
+	SELECT ` + strings.Join(columns, ", ") + ` FROM counted WHERE ` + where
+	if len(grouping.keys) > 0 {
+		positions := make([]string, len(grouping.keys))
+		for i := range positions {
+			positions[i] = fmt.Sprint(i + 1)
+		}
+		query += ` GROUP BY ` + strings.Join(positions, ", ") + ` ORDER BY ` + strings.Join(positions, ", ")
+	}
+
+	args := append([]any{from, to, conditions.SpotLocation().String()}, grouping.args...)
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []countGroup
+	for rows.Next() {
+		g := countGroup{keys: make([]int, len(grouping.keys))}
+		dest := make([]any, 0, len(g.keys)+4)
+		for i := range g.keys {
+			dest = append(dest, &g.keys[i])
+		}
+		dest = append(dest, &g.Reports, &g.Mean)
+		if !grouping.meanOnly {
+			dest = append(dest, &g.Median, &g.P90)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// GetCrowdStats aggregates the counted reports of the filter's time range
+func (s *Service) GetCrowdStats(ctx context.Context, filter EntryFilter) (*CrowdStats, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	stats := &CrowdStats{TimeZone: conditions.SpotLocation().String()}
+	if !filter.From.IsZero() {
+		from := filter.From.In(conditions.SpotLocation())
+		stats.From = &from
+	}
+	if !filter.To.IsZero() {
+		to := filter.To.In(conditions.SpotLocation())
+		stats.To = &to
+	}
+
+	groupings := []countGrouping{groupOverall, groupByWeekdayHr, groupByMonth, groupByWeather, groupByWaterLevel}
+	results := make([][]countGroup, len(groupings))
+	for i, grouping := range groupings {
+		groups, err := s.aggregateCounts(ctx, filter, grouping)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = groups
+	}
+	stats.fill(results[0], results[1], results[2], results[3], results[4])
+	return stats, nil
+}
+
+// fill sets the statistics from the groups of each grouping, see GetCrowdStats
+func (c *CrowdStats) fill(overall, weekdayHour, month, weather, waterLevel []countGroup) {
+	if len(overall) > 0 {
+		c.Overall = overall[0].CountStats
+	}
+	c.ByWeekdayHour = make([]WeekdayHourStats, 0, len(weekdayHour))
+	for _, g := range weekdayHour {
+		c.ByWeekdayHour = append(c.ByWeekdayHour, WeekdayHourStats{Weekday: g.keys[0], Hour: g.keys[1], CountStats: g.CountStats})
+	}
+	c.ByMonth = make([]MonthStats, 0, len(month))
+	for _, g := range month {
+		c.ByMonth = append(c.ByMonth, MonthStats{Month: g.keys[0], CountStats: g.CountStats})
+	}
+	c.ByWeather = make([]WeatherStats, 0, len(weather))
+	for _, g := range weather {
+		c.ByWeather = append(c.ByWeather, WeatherStats{WeatherCondition: g.keys[0], CountStats: g.CountStats})
+	}
+	c.ByWaterLevel = make([]WaterLevelStats, 0, len(waterLevel))
+	for _, g := range waterLevel {
+		c.ByWaterLevel = append(c.ByWaterLevel, WaterLevelStats{LevelFrom: g.keys[0], LevelTo: g.keys[0] + WaterLevelBand, CountStats: g.CountStats})
+	}
+}
@@ -0,0 +1,69 @@
+package surferdata
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCrowdStatsFill(t *testing.T) {
+	stats := &CrowdStats{}
+	stats.fill(nil, nil, nil, nil, nil)
+	body, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%s", body)
+	if !strings.Contains(string(body), `"by_weekday_hour":[]`) || !strings.Contains(string(body), `"by_water_level":[]`) {
+		t.Errorf("expected empty groupings to be empty arrays, got %s", body)
+	}
+
+	stats.fill(
+		[]countGroup{{CountStats: CountStats{Reports: 3, Mean: 5}}},
+		[]countGroup{{keys: []int{6, 2}, CountStats: CountStats{Reports: 1}}},
+		nil, nil,
+		[]countGroup{{keys: []int{140}, CountStats: CountStats{Reports: 2, Median: 4.5}}},
+	)
+	if stats.Overall.Reports != 3 || stats.ByWeekdayHour[0].Weekday != 6 || stats.ByWeekdayHour[0].Hour != 2 {
+		t.Errorf("unexpected groups: %+v", stats)
+	}
+	if band := stats.ByWaterLevel[0]; band.LevelFrom != 140 || band.LevelTo != 150 || band.Median != 4.5 {
+		t.Errorf("expected the 140-150 cm band, got %+v", band)
+	}
+}
+
+func TestGetCrowdStats(t *testing.T) {
+	service := setupTestService(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := service.DB.Ping(ctx); err != nil {
+		t.Skipf("test database unavailable: %v", err)
+	}
+
+	stats, err := service.GetCrowdStats(context.Background(), EntryFilter{})
+	if err != nil {
+		t.Fatalf("GetCrowdStats failed: %v", err)
+	}
+	t.Logf("%+v", stats.Overall)
+
+	// Every counted report falls into exactly one weekday and hour, and one month
+	var byHour, byMonth int
+	for _, cell := range stats.ByWeekdayHour {
+		byHour += cell.Reports
+		if cell.Median > cell.P90 || cell.Reports == 0 {
+			t.Errorf("unexpected cell %+v", cell)
+		}
+	}
+	for _, cell := range stats.ByMonth {
+		byMonth += cell.Reports
+	}
+	if byHour != stats.Overall.Reports || byMonth != stats.Overall.Reports {
+		t.Errorf("expected %d reports in every grouping, got %d by weekday and hour, %d by month", stats.Overall.Reports, byHour, byMonth)
+	}
+
+	if _, err := service.GetCrowdStats(context.Background(), EntryFilter{From: time.Now(), To: time.Now().Add(-time.Hour)}); err == nil {
+		t.Error("expected an empty range to be rejected")
+	}
+}
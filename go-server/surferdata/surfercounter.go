@@ -2,11 +2,14 @@ package surferdata
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/metrics"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/modelregistry"
 )
 
 type SurferEntry struct {
@@ -14,7 +17,7 @@ type SurferEntry struct {
 	Count            int       `json:"count"`
 	WaterTemperature *float64  `json:"water_temperature,omitempty"`
 	AirTemperature   *float64  `json:"air_temperature,omitempty"`
-	WeatherCondition *string   `json:"weather_condition,omitempty"`
+	WeatherCondition *int      `json:"weather_condition,omitempty"`
 	WaterLevel       *float64  `json:"water_level,omitempty"`
 	WaterFlow        *float64  `json:"water_flow,omitempty"`
 }
@@ -24,16 +27,21 @@ type SurferEntryResponse struct {
 	Count            int       `json:"count"`
 	WaterTemperature float64   `json:"water_temperature"`
 	AirTemperature   float64   `json:"air_temperature"`
-	WeatherCondition string    `json:"weather_condition"`
+	WeatherCondition int       `json:"weather_condition"`
 	WaterLevel       float64   `json:"water_level"`
 	WaterFlow        float64   `json:"water_flow"`
+
+	// measured, interpolated or missing per condition; conditions still being looked up are left out
+	ConditionsStatus map[conditions.HistoryField]string `json:"conditions_status,omitempty"`
 }
 
 type Service struct {
 	DB           *pgxpool.Pool
 	WaterService conditions.WaterDataProvider // ✅ use the interface here
 	AirService   conditions.AirDataProvider   // ✅ use the interface here
+	Models       *modelregistry.Registry      // optional: without it FLASK_API_URL serves ML predictions
 
+	enrichRequests chan struct{}
 }
 
 func NewService(db *pgxpool.Pool, ws conditions.WaterDataProvider, as conditions.AirDataProvider) *Service {
@@ -41,73 +49,130 @@ func NewService(db *pgxpool.Pool, ws conditions.WaterDataProvider, as conditions
 		DB:           db,
 		WaterService: ws,
 		AirService:   as,
+
+		enrichRequests: make(chan struct{}, 1),
 	}
 }
 
-func (s *Service) AddEntry(count int, when time.Time, waterTempOptional *float64) error {
+// AddEntry stores a surfer report right away, with the water temperature if the client has
+// it; the other conditions are snapshotted afterwards by EnrichConditions. Reports that look
+// anomalous are stored flagged or quarantined, see ScoreReport.
+func (s *Service) AddEntry(ctx context.Context, count int, when time.Time, waterTempOptional *float64) (AnomalyResult, error) {
 	if when.IsZero() {
 		when = time.Now()
 	}
 
-	weather, err := s.AirService.GetCurrentWeather()
-	if err != nil {
-		log.Println("⚠️ Could not fetch air weather:", err)
-		weather = &conditions.WeatherData{Temp: 0, Condition: -1}
+	snap := newConditionSnapshot()
+	if waterTempOptional != nil { // the client's current reading, fetched with the page
+		snap.set(conditions.HistoryWaterTemperature, *waterTempOptional, ConditionMeasured)
 	}
 
-	var waterTemp float64
-	if waterTempOptional != nil { // using water temp from the request, if provided (takes longer to fetch than the rest of the data)
-		waterTemp = *waterTempOptional
-	} else {
-		waterTemp, err = s.WaterService.GetCachedWaterTemperature()
-		if err != nil {
-			log.Println("⚠️ Could not fetch water temp:", err)
-			waterTemp = 0
-		}
-	}
-	var waterLevel float64
-	var waterFlow float64
-	result, err := s.WaterService.GetLatestWaterLevelAndFlow()
+	// Scored against the same hour only for now, and again once the conditions are known
+	anomaly, err := s.scoreEntry(ctx, count, when, snap)
 	if err != nil {
-		log.Println("⚠️ Could not fetch water level/flow", err)
+		slog.WarnContext(ctx, "could not score surfer report, accepting it", "err", err)
+		anomaly = AnomalyResult{Status: ReviewAccepted}
+	}
+	if anomaly.Status != ReviewAccepted {
+		slog.InfoContext(ctx, "surfer report held for review",
+			"count", count, "review_status", anomaly.Status, "anomaly_score", anomaly.Score, "reason", anomaly.Reason)
 	}
-	waterLevel = result.Level
-	waterFlow = result.Flow
 
-	_, err = s.DB.Exec(context.Background(),
-		`INSERT INTO surfer_entries (timestamp, count, water_temperature, air_temperature, weather_condition, water_level, water_flow) 
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		when, count, waterTemp, weather.Temp, weather.Condition, waterLevel, waterFlow,
+	_, err = s.DB.Exec(ctx,
+		`INSERT INTO surfer_entries (timestamp, count, water_temperature, air_temperature, weather_condition, water_level, water_flow,
+		                             conditions_status, review_status, anomaly_score, anomaly_reason)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''))`,
+		when, count,
+		snap.column(conditions.HistoryWaterTemperature), snap.column(conditions.HistoryAirTemperature),
+		snap.column(conditions.HistoryWeatherCondition), snap.column(conditions.HistoryWaterLevel),
+		snap.column(conditions.HistoryWaterFlow), snap.Status,
+		anomaly.Status, anomaly.Score, anomaly.Reason,
 	)
+	if err != nil {
+		return anomaly, err
+	}
+	metrics.SurferReports.WithLabelValues(anomaly.Status).Inc()
+	s.RequestEnrichment()
+	return anomaly, nil
+}
+
+// EntryFilter selects surfer entries by time. Zero From/To are open bounds, To is exclusive.
+type EntryFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+func (f EntryFilter) Validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("from must be before to")
+	}
+	return nil
+}
 
-	return err
+func (s *Service) GetAllEntries(ctx context.Context) ([]SurferEntryResponse, error) {
+	return s.GetEntries(ctx, EntryFilter{})
 }
 
-func (s *Service) GetAllEntries() ([]SurferEntryResponse, error) {
-	rows, err := s.DB.Query(context.Background(),
-		`SELECT timestamp, count, water_temperature, air_temperature, weather_condition, water_level, water_flow 
-		FROM surfer_entries ORDER BY timestamp DESC`)
+// GetEntries returns the counted entries in the filter's range, newest first
+func (s *Service) GetEntries(ctx context.Context, filter EntryFilter) ([]SurferEntryResponse, error) {
+	entries := []SurferEntryResponse{}
+	err := s.StreamEntries(ctx, filter, func(e SurferEntryResponse) error {
+		entries = append(entries, e)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return entries, nil
+}
+
+// StreamEntries calls fn for each counted entry in the filter's range, newest first, as the rows
+// come in from the database. It stops at the first error fn returns.
+func (s *Service) StreamEntries(ctx context.Context, filter EntryFilter, fn func(SurferEntryResponse) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+
+	rows, err := s.DB.Query(ctx,
+		`SELECT timestamp, count, water_temperature, air_temperature, weather_condition, water_level, water_flow, conditions_status
+		FROM surfer_entries
+		WHERE `+countedEntries+`
+		  AND ($1::timestamptz IS NULL OR timestamp >= $1)
+		  AND ($2::timestamptz IS NULL OR timestamp < $2)
+		ORDER BY timestamp DESC, id DESC`, from, to)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	var entries []SurferEntryResponse
 	for rows.Next() {
 		var e SurferEntry
-		if err := rows.Scan(&e.Timestamp, &e.Count, &e.WaterTemperature, &e.AirTemperature, &e.WeatherCondition, &e.WaterLevel, &e.WaterFlow); err != nil {
-			return nil, err
+		var status map[conditions.HistoryField]string
+		if err := rows.Scan(&e.Timestamp, &e.Count, &e.WaterTemperature, &e.AirTemperature, &e.WeatherCondition, &e.WaterLevel, &e.WaterFlow, &status); err != nil {
+			return err
 		}
 
-		entries = append(entries, SurferEntryResponse{
-			Timestamp:        e.Timestamp,
+		err := fn(SurferEntryResponse{
+			Timestamp:        e.Timestamp.In(conditions.SpotLocation()),
 			Count:            e.Count,
 			WaterTemperature: safeFloat(e.WaterTemperature),
 			AirTemperature:   safeFloat(e.AirTemperature),
-			WeatherCondition: safeString(e.WeatherCondition),
+			WeatherCondition: weatherConditionOrUnknown(e.WeatherCondition),
 			WaterLevel:       safeFloat(e.WaterLevel),
 			WaterFlow:        safeFloat(e.WaterFlow),
+			ConditionsStatus: status,
 		})
+		if err != nil {
+			return err
+		}
 	}
-	return entries, nil
+	return rows.Err()
 }
@@ -1,6 +1,7 @@
 package surferdata
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -10,42 +11,49 @@ import (
 type MockWaterService struct{}
 
 // GetLatestWaterTemperature implements conditions.WaterDataProvider.
-func (m *MockWaterService) GetLatestWaterTemperature() (float64, error) {
-	panic("unimplemented")
+func (m *MockWaterService) GetLatestWaterTemperature(ctx context.Context) (*conditions.WaterTemperature, error) {
+	return &conditions.WaterTemperature{Value: 15.5}, nil
 }
 
-func (m *MockWaterService) GetCachedWaterTemperature() (float64, error) {
-	return 15.5, nil
-}
-
-func (m *MockWaterService) GetLatestWaterLevelAndFlow() (*conditions.WaterLevelAndFlow, error) {
+func (m *MockWaterService) GetLatestWaterLevelAndFlow(ctx context.Context) (*conditions.WaterLevelAndFlow, error) {
 	return &conditions.WaterLevelAndFlow{
 		Level: 120.0,
 		Flow:  20.5,
 	}, nil
 }
 
+func (m *MockWaterService) GetHistoricalWaterLevels(ctx context.Context) (*conditions.WaterLevelHistory, error) {
+	return nil, nil
+}
+
 type MockAirService struct{}
 
-func (m *MockAirService) GetCurrentWeather() (*conditions.WeatherData, error) {
+func (m *MockAirService) GetCurrentWeather(ctx context.Context) (*conditions.WeatherData, error) {
 	return &conditions.WeatherData{
 		Temp:      22.3,
 		Condition: 0,
 	}, nil
 }
 
+func (m *MockAirService) GetForecast(ctx context.Context) (*conditions.WeatherForecast, error) {
+	return &conditions.WeatherForecast{
+		Units:   conditions.MetricUnits,
+		Current: conditions.CurrentConditions{Time: time.Now(), Temperature: 22.3},
+	}, nil
+}
+
 func TestAddAndGetEntries(t *testing.T) {
 	service := setupTestService(t)
 
 	service.WaterService = &MockWaterService{}
 	service.AirService = &MockAirService{}
 
-	err := service.AddEntry(5, time.Now(), nil)
+	_, err := service.AddEntry(context.Background(), 5, time.Now(), nil)
 	if err != nil {
 		t.Fatalf("Failed to add entry: %v", err)
 	}
 
-	entries, err := service.GetAllEntries()
+	entries, err := service.GetAllEntries(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to fetch entries: %v", err)
 	}
@@ -56,3 +64,26 @@ func TestAddAndGetEntries(t *testing.T) {
 
 	t.Logf("Fetched entry: %+v", entries[0])
 }
+
+func TestEntryCSVRecordFollowsHeader(t *testing.T) {
+	entry := SurferEntryResponse{
+		Timestamp:        time.Date(2025, 6, 1, 14, 0, 0, 0, conditions.SpotLocation()),
+		Count:            7,
+		WaterTemperature: 16.5,
+		AirTemperature:   24,
+		WeatherCondition: 1,
+		WaterLevel:       143,
+		WaterFlow:        21.3,
+	}
+	record := entry.CSVRecord()
+	t.Logf("%v", record)
+	if len(record) != len(EntryCSVHeader) {
+		t.Fatalf("record has %d columns, header %d", len(record), len(EntryCSVHeader))
+	}
+	if record[0] != "2025-06-01T14:00:00+02:00" || record[1] != "7" || record[6] != "21.3" {
+		t.Errorf("unexpected record %v", record)
+	}
+	if err := (EntryFilter{From: entry.Timestamp, To: entry.Timestamp}).Validate(); err == nil {
+		t.Error("expected an empty range to be rejected")
+	}
+}
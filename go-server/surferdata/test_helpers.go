@@ -12,7 +12,9 @@ func setupTestService(t *testing.T) *Service {
 
 	db := testutils.SetupTestDB(t)
 	waterService := conditions.NewWaterService()
-	airService := conditions.NewAirService()
+	airService := conditions.NewAirService(conditions.NewOpenMeteoClient(
+		"https://api.open-meteo.com/v1/forecast", 48.137154, 11.576124, 1,
+	))
 
 	return NewService(db, waterService, airService)
 }
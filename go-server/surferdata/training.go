@@ -0,0 +1,204 @@
+package surferdata
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/calendar"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
+)
+
+const (
+	SplitTrain = "train"
+	SplitTest  = "test"
+
+	DefaultTestRatio = 0.2
+	DefaultSplitSeed = "eisbach"
+)
+
+// What is known about the conditions of a training row, see TrainingRow.ConditionsStatus
+const (
+	TrainingConditionsComplete = "complete" // every condition was measured or interpolated
+	TrainingConditionsPending  = "pending"  // some are still being looked up, a later export may have them
+	TrainingConditionsMissing  = "missing"  // some were never found
+)
+
+// TrainingRow is one surfer entry joined with the conditions recorded for it,
+// using the feature names of ml-model/train_model.py. Conditions that weren't measured or
+// interpolated are nil instead of the placeholders stored for them.
+type TrainingRow struct {
+	ID               int       `json:"id" parquet:"id"`
+	Timestamp        time.Time `json:"timestamp" parquet:"timestamp,timestamp(millisecond)"`
+	Hour             int       `json:"hour" parquet:"hour"`
+	Weekday          int       `json:"weekday" parquet:"weekday"` // 0 = Monday
+	IsWeekend        int       `json:"is_weekend" parquet:"is_weekend"`
+	IsPublicHoliday  int       `json:"is_public_holiday" parquet:"is_public_holiday"`
+	IsSchoolHoliday  int       `json:"is_school_holiday" parquet:"is_school_holiday"`
+	WaterTemp        *float64  `json:"water_temp" parquet:"water_temp,optional"`
+	AirTemp          *float64  `json:"air_temp" parquet:"air_temp,optional"`
+	WeatherCondition *int      `json:"weather_condition" parquet:"weather_condition,optional"`
+	WaterLevel       *float64  `json:"water_level" parquet:"water_level,optional"`
+	WaterFlow        *float64  `json:"water_flow" parquet:"water_flow,optional"`
+	IsDaylight       int       `json:"is_daylight" parquet:"is_daylight"`
+	DayLengthHours   float64   `json:"day_length_hours" parquet:"day_length_hours"`
+	SurferCount      int       `json:"surfer_count" parquet:"surfer_count"`
+	Split            string    `json:"split" parquet:"split"`
+	ConditionsStatus string    `json:"conditions_status" parquet:"conditions_status"`
+}
+
+// TrainingFilter selects the rows of a training export. Zero From/To are open bounds, To is exclusive.
+// A zero TestRatio puts every entry into the train set; callers default it to DefaultTestRatio.
+type TrainingFilter struct {
+	From      time.Time
+	To        time.Time
+	Split     string // train | test | empty for both
+	TestRatio float64
+	Seed      string
+}
+
+// Validate fills in defaults and rejects impossible filters
+func (f *TrainingFilter) Validate() error {
+	if f.TestRatio < 0 || f.TestRatio >= 1 {
+		return fmt.Errorf("test ratio must be in [0, 1), got %v", f.TestRatio)
+	}
+	if f.Seed == "" {
+		f.Seed = DefaultSplitSeed
+	}
+	if f.Split != "" && f.Split != SplitTrain && f.Split != SplitTest {
+		return fmt.Errorf("unknown split %q, expected %q or %q", f.Split, SplitTrain, SplitTest)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("from must be before to")
+	}
+	return nil
+}
+
+// ParseTimeBound parses a filter bound given as YYYY-MM-DD (spot-local day) or RFC 3339.
+// Dates used as an upper bound include the whole day.
+func ParseTimeBound(s string, upper bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", s, conditions.SpotLocation())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD or RFC 3339", s)
+	}
+	if upper {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// AssignSplit puts an entry into the train or test set. The split only depends on
+// the entry id and the seed, so it doesn't change when new data comes in or the time range changes.
+func AssignSplit(id int, seed string, testRatio float64) string {
+	h := fnv.New32a()
+	h.Write([]byte(seed + ":" + strconv.Itoa(id)))
+	if float64(h.Sum32()%10000) < testRatio*10000 {
+		return SplitTest
+	}
+	return SplitTrain
+}
+
+// GetTrainingData returns the feature table for the ML pipeline, oldest entries first
+func (s *Service) GetTrainingData(ctx context.Context, filter TrainingFilter) ([]TrainingRow, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+
+	rows, err := s.DB.Query(ctx,
+		`SELECT id, timestamp, count, water_temperature, air_temperature, weather_condition, water_level, water_flow, conditions_status
+		FROM surfer_entries
+		WHERE `+countedEntries+`
+		  AND ($1::timestamptz IS NULL OR timestamp >= $1)
+		  AND ($2::timestamptz IS NULL OR timestamp < $2)
+		ORDER BY timestamp, id`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []TrainingRow
+	for rows.Next() {
+		var (
+			id, count, weatherCondition int
+			timestamp                   time.Time
+			waterTemp, airTemp          float64
+			waterLevel, waterFlow       *float64
+			status                      map[conditions.HistoryField]string
+		)
+		if err := rows.Scan(&id, &timestamp, &count, &waterTemp, &airTemp, &weatherCondition, &waterLevel, &waterFlow, &status); err != nil {
+			return nil, err
+		}
+
+		row := newTrainingRow(id, timestamp, count, filter)
+		row.setConditions(storedSnapshot(waterTemp, airTemp, weatherCondition, waterLevel, waterFlow, status))
+
+		if filter.Split == "" || filter.Split == row.Split {
+			out = append(out, row)
+		}
+	}
+	return out, rows.Err()
+}
+
+// newTrainingRow derives the time features of an entry from the spot's local time
+func newTrainingRow(id int, at time.Time, count int, filter TrainingFilter) TrainingRow {
+	at = at.In(conditions.SpotLocation())
+	day := calendar.For(at)
+	daylight := conditions.SunTimes(at, config.Spot.Latitude, config.Spot.Longitude)
+
+	return TrainingRow{
+		ID:              id,
+		Timestamp:       at,
+		Hour:            at.Hour(),
+		Weekday:         day.MondayBasedWeekday(),
+		IsWeekend:       boolToInt(day.IsWeekend),
+		IsPublicHoliday: boolToInt(day.IsPublicHoliday()),
+		IsSchoolHoliday: boolToInt(day.IsSchoolHoliday()),
+		IsDaylight:      boolToInt(daylight.IsLight(at)),
+		DayLengthHours:  daylight.DayHours,
+		SurferCount:     count,
+		Split:           AssignSplit(id, filter.Seed, filter.TestRatio),
+	}
+}
+
+// setConditions takes the known conditions of snap; placeholders of failed or pending
+// fetches stay nil, so they don't end up as features
+func (r *TrainingRow) setConditions(snap ConditionSnapshot) {
+	known := func(field conditions.HistoryField) *float64 {
+		if v, ok := snap.known(field); ok {
+			return &v
+		}
+		return nil
+	}
+	r.WaterTemp = known(conditions.HistoryWaterTemperature)
+	r.AirTemp = known(conditions.HistoryAirTemperature)
+	if weather := known(conditions.HistoryWeatherCondition); weather != nil {
+		code := int(*weather)
+		r.WeatherCondition = &code
+	}
+	r.WaterLevel = known(conditions.HistoryWaterLevel)
+	r.WaterFlow = known(conditions.HistoryWaterFlow)
+
+	r.ConditionsStatus = TrainingConditionsComplete
+	if len(snap.pending()) > 0 {
+		r.ConditionsStatus = TrainingConditionsPending
+	} else if r.WaterTemp == nil || r.AirTemp == nil || r.WeatherCondition == nil || r.WaterLevel == nil || r.WaterFlow == nil {
+		r.ConditionsStatus = TrainingConditionsMissing
+	}
+}
@@ -0,0 +1,111 @@
+package surferdata
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ExportFormat is a file format of the training export
+type ExportFormat string
+
+const (
+	FormatCSV     ExportFormat = "csv"
+	FormatJSONL   ExportFormat = "jsonl"
+	FormatParquet ExportFormat = "parquet"
+)
+
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch f := ExportFormat(s); f {
+	case FormatCSV, FormatJSONL, FormatParquet:
+		return f, nil
+	case "":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("unsupported format %q, expected csv, jsonl or parquet", s)
+}
+
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+var trainingCSVHeader = []string{
+	"id", "timestamp", "hour", "weekday", "is_weekend", "is_public_holiday", "is_school_holiday",
+	"water_temp", "air_temp", "weather_condition", "water_level", "water_flow",
+	"is_daylight", "day_length_hours", "surfer_count", "split", "conditions_status",
+}
+
+// WriteTrainingData writes the rows in the given format
+func WriteTrainingData(w io.Writer, format ExportFormat, rows []TrainingRow) error {
+	switch format {
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatParquet:
+		return parquet.Write(w, rows)
+
+	default:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(trainingCSVHeader); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := cw.Write(row.csvRecord()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+}
+
+func (r TrainingRow) csvRecord() []string {
+	float := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	// Unknown conditions are empty
+	optional := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return float(*f)
+	}
+	weather := ""
+	if r.WeatherCondition != nil {
+		weather = strconv.Itoa(*r.WeatherCondition)
+	}
+	return []string{
+		strconv.Itoa(r.ID),
+		r.Timestamp.Format(time.RFC3339),
+		strconv.Itoa(r.Hour),
+		strconv.Itoa(r.Weekday),
+		strconv.Itoa(r.IsWeekend),
+		strconv.Itoa(r.IsPublicHoliday),
+		strconv.Itoa(r.IsSchoolHoliday),
+		optional(r.WaterTemp),
+		optional(r.AirTemp),
+		weather,
+		optional(r.WaterLevel),
+		optional(r.WaterFlow),
+		strconv.Itoa(r.IsDaylight),
+		float(r.DayLengthHours),
+		strconv.Itoa(r.SurferCount),
+		r.Split,
+		r.ConditionsStatus,
+	}
+}
@@ -0,0 +1,171 @@
+package surferdata
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/testutils"
+)
+
+func TestAssignSplitIsDeterministic(t *testing.T) {
+	test := 0
+	for id := 1; id <= 1000; id++ {
+		split := AssignSplit(id, DefaultSplitSeed, 0.2)
+		if split != AssignSplit(id, DefaultSplitSeed, 0.2) {
+			t.Fatalf("split of entry %d changed between calls", id)
+		}
+		if split == SplitTest {
+			test++
+		}
+	}
+
+	t.Logf("%d of 1000 entries in the test set", test)
+	if test < 150 || test > 250 {
+		t.Errorf("expected roughly 20%% test entries, got %d", test)
+	}
+}
+
+func TestZeroTestRatioIsAllTrain(t *testing.T) {
+	filter := TrainingFilter{TestRatio: 0}
+	if err := filter.Validate(); err != nil || filter.TestRatio != 0 {
+		t.Fatalf("expected a test ratio of 0 to be kept, got %v, %v", filter.TestRatio, err)
+	}
+	for id := 1; id <= 1000; id++ {
+		if split := AssignSplit(id, filter.Seed, filter.TestRatio); split != SplitTrain {
+			t.Fatalf("expected entry %d in the train set, got %s", id, split)
+		}
+	}
+}
+
+func TestNewTrainingRowFeatures(t *testing.T) {
+	testutils.LoadTestConfig(t)
+
+	// Thursday 19 June 2025, 7pm in Munich (Fronleichnam), as scanned from the database
+	at := time.Date(2025, 6, 19, 17, 0, 0, 0, time.UTC)
+	row := newTrainingRow(42, at, 12, TrainingFilter{Seed: DefaultSplitSeed, TestRatio: 0.2})
+
+	if row.Hour != 19 || row.Weekday != 3 || row.IsWeekend != 0 || row.IsPublicHoliday != 1 {
+		t.Errorf("unexpected calendar features: %+v", row)
+	}
+	if row.IsDaylight != 1 || row.DayLengthHours < 16 {
+		t.Errorf("expected a long, light June evening: %+v", row)
+	}
+	if row.SurferCount != 12 || row.Split == "" {
+		t.Errorf("unexpected target/split: %+v", row)
+	}
+}
+
+func TestNewTrainingRowHourAcrossDST(t *testing.T) {
+	testutils.LoadTestConfig(t)
+
+	cases := []struct {
+		at   time.Time
+		hour int
+	}{
+		// 30 March 2025: 02:00 CET is 03:00 CEST, there is no 2am
+		{time.Date(2025, 3, 30, 0, 30, 0, 0, time.UTC), 1},
+		{time.Date(2025, 3, 30, 1, 30, 0, 0, time.UTC), 3},
+		// 26 October 2025: 03:00 CEST is 02:00 CET, 2am happens twice
+		{time.Date(2025, 10, 26, 0, 30, 0, 0, time.UTC), 2},
+		{time.Date(2025, 10, 26, 1, 30, 0, 0, time.UTC), 2},
+		{time.Date(2025, 10, 26, 22, 30, 0, 0, time.UTC), 23},
+		// Saturday night in UTC, Sunday in Munich
+		{time.Date(2025, 10, 25, 22, 30, 0, 0, time.UTC), 0},
+	}
+	for _, tc := range cases {
+		row := newTrainingRow(1, tc.at, 3, TrainingFilter{Seed: DefaultSplitSeed, TestRatio: 0.2})
+		if row.Hour != tc.hour || row.Weekday != 6 || row.IsWeekend != 1 {
+			t.Errorf("%s: got hour %d, weekday %d; want hour %d on a Sunday", tc.at, row.Hour, row.Weekday, tc.hour)
+		}
+		if _, offset := row.Timestamp.Zone(); offset != 3600 && offset != 7200 {
+			t.Errorf("%s: expected the timestamp in spot time, got %s", tc.at, row.Timestamp)
+		}
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	from, err := ParseTimeBound("2025-06-01", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := ParseTimeBound("2025-06-30", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := to.Sub(from); got != 30*24*time.Hour {
+		t.Errorf("expected the upper date to include the whole day, range is %v", got)
+	}
+
+	if _, err := ParseTimeBound("yesterday", false); err == nil {
+		t.Error("expected invalid bound to be rejected")
+	}
+}
+
+func TestWriteTrainingData(t *testing.T) {
+	temp, level := 15.5, 140.0
+	rows := []TrainingRow{
+		{ID: 1, Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), Hour: 14, WaterTemp: &temp, WaterLevel: &level, SurferCount: 8, Split: SplitTrain},
+		{ID: 2, Timestamp: time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC), Hour: 9, SurferCount: 3, Split: SplitTest, ConditionsStatus: TrainingConditionsPending},
+	}
+
+	var csvOut bytes.Buffer
+	if err := WriteTrainingData(&csvOut, FormatCSV, rows); err != nil {
+		t.Fatalf("csv export failed: %v", err)
+	}
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatalf("csv export is not readable: %v", err)
+	}
+	if len(records) != 3 || records[0][7] != "water_temp" || records[1][7] != "15.5" {
+		t.Errorf("unexpected csv export: %v", records)
+	}
+	if records[2][7] != "" || records[2][9] != "" || records[2][16] != TrainingConditionsPending {
+		t.Errorf("expected unknown conditions to be empty: %v", records[2])
+	}
+
+	var parquetOut bytes.Buffer
+	if err := WriteTrainingData(&parquetOut, FormatParquet, rows); err != nil {
+		t.Fatalf("parquet export failed: %v", err)
+	}
+	back, err := parquet.Read[TrainingRow](bytes.NewReader(parquetOut.Bytes()), int64(parquetOut.Len()))
+	if err != nil {
+		t.Fatalf("parquet export is not readable: %v", err)
+	}
+	if len(back) != 2 || back[1].SurferCount != 3 || back[1].Split != SplitTest {
+		t.Errorf("unexpected parquet round trip: %+v", back)
+	}
+	if back[0].WaterTemp == nil || *back[0].WaterTemp != 15.5 || back[1].WaterTemp != nil {
+		t.Errorf("expected water_temp to round trip as optional: %v, %v", back[0].WaterTemp, back[1].WaterTemp)
+	}
+}
+
+func TestTrainingRowLeavesOutPlaceholders(t *testing.T) {
+	level := 0.0
+	status := map[conditions.HistoryField]string{
+		conditions.HistoryWaterTemperature: ConditionMeasured,
+		conditions.HistoryAirTemperature:   ConditionInterpolated,
+		conditions.HistoryWeatherCondition: ConditionMissing,
+		conditions.HistoryWaterLevel:       ConditionMissing,
+		conditions.HistoryWaterFlow:        ConditionMeasured,
+	}
+
+	var row TrainingRow
+	row.setConditions(storedSnapshot(14.5, 21, -1, &level, &level, status))
+	if row.WaterTemp == nil || *row.WaterTemp != 14.5 || row.AirTemp == nil || row.WaterFlow == nil {
+		t.Errorf("expected measured and interpolated conditions to be exported: %+v", row)
+	}
+	if row.WeatherCondition != nil || row.WaterLevel != nil || row.ConditionsStatus != TrainingConditionsMissing {
+		t.Errorf("expected the placeholders of missing conditions to be left out: %+v", row)
+	}
+
+	// Stored right away, the fetch hasn't run yet
+	var pending TrainingRow
+	pending.setConditions(storedSnapshot(0, 0, -1, nil, nil, nil))
+	if pending.WaterTemp != nil || pending.WeatherCondition != nil || pending.ConditionsStatus != TrainingConditionsPending {
+		t.Errorf("expected a pending entry without conditions: %+v", pending)
+	}
+}
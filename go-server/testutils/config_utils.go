@@ -4,14 +4,26 @@ import (
 	"os"
 	"testing"
 
+	"github.com/vr33ni/eisbachtracker-pwa/go-server/conditions"
 	"github.com/vr33ni/eisbachtracker-pwa/go-server/config"
 )
 
 func LoadTestConfig(t *testing.T) {
 	os.Setenv("PREDICT_CONFIG", "../config/predict.toml")
+	os.Setenv("CALENDAR_CONFIG", "../config/calendar.toml")
 	os.Setenv("FLASK_API_URL", "http://localhost:5001/predict")
 
 	if err := config.LoadConfig(); err != nil {
 		t.Fatalf("Failed to load config: %v", err)
 	}
+	if err := config.LoadSpotConfig(); err != nil {
+		t.Fatalf("Failed to load spot config: %v", err)
+	}
+	conditions.SetSpotLocation(config.Spot.Location)
+	if err := config.LoadCalendarConfig(); err != nil {
+		t.Fatalf("Failed to load calendar config: %v", err)
+	}
+	if err := config.LoadAnomalyConfig(); err != nil {
+		t.Fatalf("Failed to load anomaly config: %v", err)
+	}
 }
@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryTracerRecordsSQLWithoutArgs(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	tracer := QueryTracer{}
+	sql := "\n\t\tselect AVG(count) FROM surfer_entries WHERE EXTRACT(HOUR FROM timestamp) = $1"
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: sql, Args: []any{"secret-arg"}})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1"), Err: errors.New("boom")})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	span := spans[0]
+	t.Logf("span %q: %v", span.Name, span.Attributes)
+
+	if span.Name != "db SELECT" {
+		t.Errorf("expected span name %q, got %q", "db SELECT", span.Name)
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("expected an error status, got %v", span.Status.Code)
+	}
+	for _, attr := range span.Attributes {
+		if attr.Value.Emit() == "secret-arg" {
+			t.Errorf("query arguments must not be recorded, found in %s", attr.Key)
+		}
+	}
+}
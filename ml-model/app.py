@@ -7,10 +7,24 @@ import os
 app = Flask(__name__)
 model = joblib.load("surfer_prediction_model.pkl")
 
+# Versioned models for the Go server's model registry, loaded from models/<version>.pkl on first use
+MODEL_DIR = os.environ.get("MODEL_DIR", "models")
+versioned_models = {}
+
+@app.route("/models/<version>/predict", methods=["POST"])
+def predict_version(version):
+    if version not in versioned_models:
+        path = os.path.join(MODEL_DIR, os.path.basename(version) + ".pkl")
+        if not os.path.exists(path):
+            return jsonify({"error": f"unknown model version {version}"}), 404
+        versioned_models[version] = joblib.load(path)
+    return run_prediction(versioned_models[version], request.json)
+
 @app.route("/predict", methods=["POST"])
 def predict():
-    data = request.json
+    return run_prediction(model, request.json)
 
+def run_prediction(model, data):
     # Create a DataFrame with the same column names as the training data
     feature_dict = {
         "hour": data["hour"],
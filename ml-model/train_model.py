@@ -1,11 +1,18 @@
+import sys
 import pandas as pd
 from sklearn.model_selection import train_test_split
 from sklearn.linear_model import LinearRegression
 from sklearn.metrics import mean_squared_error
 import joblib
 
-# Load data
-df = pd.read_csv("combined_feature_and_target_data.csv")
+# Load data: an export from the Go server (go run . export-training) or the generated dummy data
+path = sys.argv[1] if len(sys.argv) > 1 else "combined_feature_and_target_data.csv"
+if path.endswith(".parquet"):
+    df = pd.read_parquet(path)
+elif path.endswith(".jsonl"):
+    df = pd.read_json(path, lines=True)
+else:
+    df = pd.read_csv(path)
 
 # Ensure all one-hot encoded columns are present
 expected_columns = [
@@ -16,12 +23,19 @@ for col in expected_columns:
     if col not in df.columns:
         df[col] = 0  # Add missing columns with default value 0
 
+# Conditions that weren't measured are empty in the Go server's export, not 0
+df = df.dropna(subset=expected_columns)
+
 # Features (X) and target (y)
 X = df[expected_columns]  # Use only the expected columns
 y = df["surfer_count"]  # Target column
 
-# Split data into training and testing sets
-X_train, X_test, y_train, y_test = train_test_split(X, y, test_size=0.2, random_state=42)
+# Split data into training and testing sets, keeping the export's split if there is one
+if "split" in df.columns:
+    is_test = df["split"] == "test"
+    X_train, X_test, y_train, y_test = X[~is_test], X[is_test], y[~is_test], y[is_test]
+else:
+    X_train, X_test, y_train, y_test = train_test_split(X, y, test_size=0.2, random_state=42)
 
 # Train a linear regression model
 model = LinearRegression()